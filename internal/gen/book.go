@@ -1,117 +1,108 @@
 package gen
 
 import (
+	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"strings"
 
 	"github.com/jcorbin/markov/internal/model"
-	"github.com/jcorbin/markov/internal/symbol"
 )
 
 var errStop = errors.New("done")
 
+// defaultLanguage is the book language code if no supporting document's is
+// known.
+const defaultLanguage = "en"
+
 func (g gen) GenBook(title string, docs model.SupportDocIDs, w io.Writer) error {
+	return g.RenderBook(title, docs, NewTextRenderer(w))
+}
+
+func (g gen) RenderBook(title string, docs model.SupportDocIDs, r Renderer) (rerr error) {
+	defer func() {
+		if cerr := r.Close(); rerr == nil {
+			rerr = cerr
+		}
+	}()
+	ids := docs.Ranked()
+	lang := g.db.DocsLanguage(ids)
+	if lang == "" {
+		lang = defaultLanguage
+	}
+	if err := r.Language(lang); err != nil {
+		return err
+	}
 	title = strings.Title(title)
-	if _, err := fmt.Fprintf(w, "Title: %q\n", title); err != nil {
+	if err := r.Title(title); err != nil {
 		return err
 	}
-	if err := g.writeDocIDs(docs, w); err != nil {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = g.db.DocName(id)
+	}
+	if err := r.SupportDocs(names); err != nil {
 		return err
 	}
-	lng, err := g.db.MergedDocLang(docs)
+	lng, err := g.mergedLang(docs)
 	if err != nil {
 		return err
 	}
-	return g.write(title, lng, w)
-}
-
-func (g gen) writeDocIDs(docs model.SupportDocIDs, w io.Writer) error {
-	if _, err := fmt.Fprintf(w, "\nSupporting Docs:\n"); err != nil {
-		return err
+	if g.provenance == nil {
+		return g.write(docs, lng, r, nil)
 	}
-	for id := range docs {
-		if _, err := fmt.Fprintf(w, "- %q\n", id); err != nil {
-			return err
-		}
+	prov := provenance{Title: title}
+	if err := g.write(docs, lng, r, &prov); err != nil {
+		return err
 	}
-	_, err := w.Write([]byte("\n"))
-	return err
+	enc := json.NewEncoder(g.provenance)
+	return enc.Encode(prov)
 }
 
-func (g gen) write(title string, lng model.Lang, w io.Writer) error {
-	const (
-		limit    = 10000
-		lineWrap = 80 - 1
-	)
+type provenance struct {
+	Title string           `json:"title"`
+	Words []provenanceWord `json:"words"`
+}
 
-	head := strings.ToUpper(title)
-	if n := (lineWrap - len(head)) / 2; n > 0 {
-		head = strings.Repeat(" ", n) + head
-	}
-	if _, err := fmt.Fprintf(w, "%s\n\n", head); err != nil {
-		return err
-	}
+type provenanceWord struct {
+	Offset  int              `json:"offset"`
+	Word    string           `json:"word"`
+	Sources []model.DocShare `json:"sources"`
+}
 
+func (g gen) write(docs model.SupportDocIDs, lng model.MergedLang, r Renderer, prov *provenance) error {
 	// TODO: handle punctuation better
 	// TODO: maybe section headers
-
-	// TODO: reify state, break apart the chain callback
-	lg := lineGen{w: w}
-	lg.buf.Grow(lineWrap + 2)
-	chainLength := 0
-	first := true
-
-	err := lng.Trans.GenChain(g.rng, func(sym symbol.Symbol) error {
-		switch sym {
-		case 0, symbol.EOF:
-			return errStop
-		case symbol.GS:
-			first = true
-			lg.buf.WriteRune('\n')
-			return lg.flush()
-		}
-
-		word := lng.Dict.ToString(sym)
-		if err := lg.flushIfExceeds(len(word), lineWrap); err != nil {
+	var sentence []string
+	return g.genBody(docs, lng, func(ev Event) error {
+		switch ev.Type {
+		case EventWord:
+			sentence = append(sentence, ev.Word)
+			if prov != nil {
+				prov.Words = append(prov.Words, provenanceWord{ev.N, ev.Word, ev.Sources})
+			}
+		case EventSentenceEnd:
+			err := r.Sentence(sentence)
+			sentence = sentence[:0]
 			return err
-		}
-
-		if lg.buf.Len() > 0 {
-			_, _ = lg.buf.WriteRune(' ')
-		}
-		if first {
-			_, _ = lg.buf.WriteString(strings.Title(word))
-			first = false
-		} else {
-			_, _ = lg.buf.WriteString(word)
-		}
-
-		chainLength++
-		switch word {
-		case ".", "!", "?":
-			first = true
-			if chainLength >= limit {
-				// TODO: approach / generate / work-in EOF more naturally
-				lg.buf.WriteRune('\n')
-				if err := lg.flush(); err != nil {
+		case EventLine:
+			if len(sentence) > 0 {
+				// the rest of the sentence continues on the next line
+				if err := r.Sentence(sentence); err != nil {
 					return err
 				}
-				fmt.Fprintf(&lg.buf, "-- Cut off by editorial oversight: exceeded %v words", limit)
-				if err := lg.flush(); err != nil {
-					return err
-				}
-				return errStop
+				sentence = sentence[:0]
+			}
+			return r.LineBreak()
+		case EventParagraph:
+			return r.EndParagraph()
+		case EventChapter:
+			return r.Chapter(ev.N)
+		case EventEnd:
+			if g.colophon {
+				return r.Colophon("THE END")
 			}
 		}
-
 		return nil
 	})
-
-	if err == nil || err == errStop {
-		err = lg.flush()
-	}
-
-	return err
 }
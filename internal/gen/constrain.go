@@ -0,0 +1,94 @@
+package gen
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jcorbin/markov/internal/model"
+	"github.com/jcorbin/markov/internal/symbol"
+)
+
+var errUnsatisfied = errors.New("unable to satisfy inclusion constraints")
+
+// includeBoost is the factor by which the weight of a yet-to-be included
+// word is boosted, to guide generation towards satisfying Constraints.
+const includeBoost = 10
+
+// Constraints constrain generated text.
+type Constraints struct {
+	// Start seeds generation: generated text begins with these words, and
+	// chaining continues from the last of them.
+	Start []string
+
+	// End words end generated text; the text is bridged into the first of
+	// them by meeting a forward chain with a backward one.
+	End []string
+
+	// Include lists words that generated text should contain; generation is
+	// guided towards them.
+	Include []string
+
+	// Hard makes Include a requirement, rather than a preference: text that
+	// doesn't contain every word is rejected.
+	Hard bool
+}
+
+// missing returns the Include words not found in the given text words.
+func (c Constraints) missing(words []string) []string {
+	var miss []string
+	for _, inc := range c.Include {
+		found := false
+		for _, word := range words {
+			if word == inc {
+				found = true
+				break
+			}
+		}
+		if !found {
+			miss = append(miss, inc)
+		}
+	}
+	return miss
+}
+
+// needs returns the set of Include symbols in the given language, and any
+// Include words not in its dictionary; unknown words are an error if the
+// constraints are hard.
+func (c Constraints) needs(lng model.Lang) (map[symbol.Symbol]bool, []string, error) {
+	if len(c.Include) == 0 {
+		return nil, nil, nil
+	}
+	var unknown []string
+	need := make(map[symbol.Symbol]bool, len(c.Include))
+	for _, word := range c.Include {
+		sym, def := lng.Dict.GetSym(word)
+		if !def {
+			if c.Hard {
+				return nil, nil, errUnsatisfied
+			}
+			unknown = append(unknown, word)
+			continue
+		}
+		need[sym] = true
+	}
+	return need, unknown, nil
+}
+
+// titleBias returns a bias towards the Include words.
+func (c Constraints) titleBias(lng model.Lang) model.Bias {
+	need, _, _ := c.needs(lng)
+	if len(need) == 0 {
+		return nil
+	}
+	return func(sym symbol.Symbol, w uint) float64 {
+		if need[sym] {
+			return includeBoost * float64(w)
+		}
+		return float64(w)
+	}
+}
+
+// titleMissing returns the Include words missing from a generated title.
+func (c Constraints) titleMissing(title string) []string {
+	return c.missing(strings.Fields(title))
+}
@@ -0,0 +1,104 @@
+package gen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// titleIndex indexes existing titles, after normalization, to find
+// duplicates of generated titles.
+type titleIndex struct {
+	exact map[string]string
+	byLen map[int][]string
+}
+
+// normalizeTitle case folds a title, strips punctuation, and collapses
+// space.
+func normalizeTitle(title string) string {
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(title)) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsNumber(r) {
+				return r
+			}
+			return -1
+		}, word)
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func newTitleIndex(titles []string) titleIndex {
+	ti := titleIndex{
+		exact: make(map[string]string, len(titles)),
+		byLen: make(map[int][]string),
+	}
+	for _, title := range titles {
+		norm := normalizeTitle(title)
+		if _, def := ti.exact[norm]; def {
+			continue
+		}
+		ti.exact[norm] = title
+		n := len([]rune(norm))
+		ti.byLen[n] = append(ti.byLen[n], norm)
+	}
+	return ti
+}
+
+// dupOf returns the existing title that the given title duplicates: either
+// exactly after normalization, or within maxDist edits if maxDist > 0.
+func (ti titleIndex) dupOf(title string, maxDist int) (string, bool) {
+	norm := normalizeTitle(title)
+	if orig, def := ti.exact[norm]; def {
+		return orig, true
+	}
+	if maxDist <= 0 {
+		return "", false
+	}
+	a := []rune(norm)
+	for n := len(a) - maxDist; n <= len(a)+maxDist; n++ {
+		for _, other := range ti.byLen[n] {
+			if editDistance(a, []rune(other), maxDist) <= maxDist {
+				return ti.exact[other], true
+			}
+		}
+	}
+	return "", false
+}
+
+// editDistance returns the Levenshtein distance between a and b, or some
+// value greater than max once it's known to exceed max.
+func editDistance(a, b []rune, max int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(b); j++ {
+			d := prev[j-1]
+			if a[i-1] != b[j-1] {
+				d++
+			}
+			if del := prev[j] + 1; del < d {
+				d = del
+			}
+			if ins := cur[j-1] + 1; ins < d {
+				d = ins
+			}
+			cur[j] = d
+			if d < best {
+				best = d
+			}
+		}
+		if best > max {
+			return best
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,241 @@
+package gen
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"io"
+	"time"
+)
+
+type epubRenderer struct {
+	w        io.Writer
+	lang     string
+	title    string
+	support  bytes.Buffer
+	chapters []*bytes.Buffer
+	para     bool
+	brk      bool
+}
+
+// NewEPUBRenderer creates a Renderer that writes an EPUB 3 zip archive; since
+// the archive's manifest must list every chapter, all content is buffered
+// until Close.
+func NewEPUBRenderer(w io.Writer) Renderer {
+	return &epubRenderer{w: w}
+}
+
+func (er *epubRenderer) Language(code string) error {
+	er.lang = code
+	return nil
+}
+
+func (er *epubRenderer) Title(title string) error {
+	er.title = title
+	return nil
+}
+
+func (er *epubRenderer) SupportDocs(names []string) error {
+	er.support.WriteString("<h2>Supporting Docs</h2>\n<ul>\n")
+	for _, name := range names {
+		fmt.Fprintf(&er.support, "<li>%s</li>\n", html.EscapeString(name))
+	}
+	er.support.WriteString("</ul>\n")
+	return nil
+}
+
+func (er *epubRenderer) Chapter(n int) error {
+	er.endParagraph()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<h2>Chapter %d</h2>\n", n)
+	er.chapters = append(er.chapters, &buf)
+	return nil
+}
+
+func (er *epubRenderer) cur() *bytes.Buffer {
+	if len(er.chapters) == 0 {
+		_ = er.Chapter(1)
+	}
+	return er.chapters[len(er.chapters)-1]
+}
+
+func (er *epubRenderer) Sentence(words []string) error {
+	buf := er.cur()
+	if er.brk {
+		buf.WriteString("<br/>\n")
+		er.brk = false
+	} else if er.para {
+		buf.WriteByte(' ')
+	} else {
+		buf.WriteString("<p>")
+		er.para = true
+	}
+	buf.WriteString(html.EscapeString(sentenceText(words)))
+	return nil
+}
+
+func (er *epubRenderer) LineBreak() error {
+	er.brk = er.para
+	return nil
+}
+
+func (er *epubRenderer) EndParagraph() error {
+	er.endParagraph()
+	return nil
+}
+
+func (er *epubRenderer) endParagraph() {
+	if er.para {
+		er.para, er.brk = false, false
+		er.cur().WriteString("</p>\n")
+	}
+}
+
+func (er *epubRenderer) Colophon(text string) error {
+	er.endParagraph()
+	fmt.Fprintf(er.cur(), "<p class=\"colophon\">%s</p>\n", html.EscapeString(text))
+	return nil
+}
+
+const (
+	epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+	epubXHTMLHead = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<meta charset="utf-8"/>
+<title>%s</title>
+</head>
+<body>
+`
+	epubXHTMLTail = "</body>\n</html>\n"
+)
+
+func (er *epubRenderer) Close() error {
+	er.endParagraph()
+	title := html.EscapeString(er.title)
+
+	id, err := newUUID()
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(er.w)
+
+	if err := writeMimetype(zw); err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, epubFile("META-INF/container.xml"), epubContainer); err != nil {
+		return err
+	}
+
+	var opf, nav bytes.Buffer
+	fmt.Fprintf(&opf, `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="bookid">urn:uuid:%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="support" href="support.xhtml" media-type="application/xhtml+xml"/>
+`, id, title, html.EscapeString(er.lang), time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	fmt.Fprintf(&nav, epubXHTMLHead, title)
+	fmt.Fprintf(&nav, "<nav epub:type=\"toc\" id=\"toc\">\n<h1>%s</h1>\n<ol>\n", title)
+	nav.WriteString("<li><a href=\"support.xhtml\">Supporting Docs</a></li>\n")
+	for i := range er.chapters {
+		fmt.Fprintf(&opf, "<item id=\"chapter-%d\" href=\"chapter-%d.xhtml\" media-type=\"application/xhtml+xml\"/>\n", i+1, i+1)
+		fmt.Fprintf(&nav, "<li><a href=\"chapter-%d.xhtml\">Chapter %d</a></li>\n", i+1, i+1)
+	}
+	// supporting docs come first, as in the other formats
+	opf.WriteString("</manifest>\n<spine>\n<itemref idref=\"support\"/>\n")
+	for i := range er.chapters {
+		fmt.Fprintf(&opf, "<itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	opf.WriteString("</spine>\n</package>\n")
+	nav.WriteString("</ol>\n</nav>\n")
+	nav.WriteString(epubXHTMLTail)
+
+	if err := writeZipFile(zw, epubFile("OEBPS/content.opf"), opf.String()); err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, epubFile("OEBPS/nav.xhtml"), nav.String()); err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, epubFile("OEBPS/support.xhtml"),
+		fmt.Sprintf(epubXHTMLHead, title), er.support.String(), epubXHTMLTail,
+	); err != nil {
+		return err
+	}
+	for i, buf := range er.chapters {
+		if err := writeZipFile(zw, epubFile(fmt.Sprintf("OEBPS/chapter-%d.xhtml", i+1)),
+			fmt.Sprintf(epubXHTMLHead, title), buf.String(), epubXHTMLTail,
+		); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeMimetype writes the mimetype entry, which must be first, stored
+// uncompressed, and without any data descriptor or extra header fields (so no
+// modification time); CreateHeader would always add a data descriptor, so the
+// entry is written raw, with its CRC and sizes in the local header.
+func writeMimetype(zw *zip.Writer) error {
+	const mimetype = "application/epub+zip"
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE([]byte(mimetype)),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, mimetype)
+	return err
+}
+
+func epubFile(name string) *zip.FileHeader {
+	return &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	}
+}
+
+func writeZipFile(zw *zip.Writer, fh *zip.FileHeader, parts ...string) error {
+	w, err := zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if _, err := io.WriteString(w, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
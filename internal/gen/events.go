@@ -0,0 +1,382 @@
+package gen
+
+import (
+	"github.com/jcorbin/markov/internal/model"
+	"github.com/jcorbin/markov/internal/symbol"
+)
+
+// EventType identifies the kind of a generation Event.
+type EventType uint8
+
+const (
+	// EventWord carries a generated word (or punctuation token).
+	EventWord EventType = iota + 1
+
+	// EventSentenceEnd ends the current sentence; every run of words is
+	// ended by one, even if generation didn't produce terminal punctuation.
+	EventSentenceEnd
+
+	// EventParagraph ends the current paragraph.
+	EventParagraph
+
+	// EventChapter starts a new chapter, numbered by Event.N; the first
+	// comes before any other event, and later ones at paragraph ends once
+	// the chapter is long enough, see WithChapterLength.
+	EventChapter
+
+	// EventEnd is the final event of the body.
+	EventEnd
+
+	// EventLine ends a line of verse, which may be within a sentence; it's
+	// only generated WithVerse, where EventParagraph ends a stanza.
+	EventLine
+)
+
+var eventTypeNames = []string{"", "word", "sentenceEnd", "paragraph", "chapter", "end", "line"}
+
+func (t EventType) String() string {
+	if int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return "unknown"
+}
+
+// Event is a structured body generation event.
+type Event struct {
+	Type EventType
+
+	// Symbol is the source symbol of an EventWord, in the generating
+	// language's dictionary; Word is its string.
+	Symbol symbol.Symbol
+	Word   string
+
+	// N is the chapter number for EventChapter, and the count of words
+	// generated so far for all other events.
+	N int
+
+	// Sources lists the supporting documents that contributed the
+	// transition to an EventWord; only tracked if provenance is enabled.
+	Sources []model.DocShare
+}
+
+// bodyGen holds body generation state, emitting Events as it goes; word
+// events are held until the end of their sentence, so that a sentence may be
+// rejected and resampled.
+type bodyGen struct {
+	g        gen
+	lng      model.MergedLang
+	grams    *model.NGramIndex
+	stats    *Stats
+	emit     func(Event) error
+	last     symbol.Symbol
+	words    int
+	sent     []Event
+	sentLast symbol.Symbol
+	tries    int
+	inPara   bool
+	need     map[symbol.Symbol]bool
+	ending   bool
+
+	// recent holds the last maxVerbatim words emitted in the current
+	// paragraph, so that copied runs are caught across sentences
+	recent []Event
+
+	// chapter is the current chapter number, started after chapterStart
+	// words; chapterDue is set once it's long enough to end at the last
+	// paragraph end, the next chapter starting with the next sentence
+	chapter      int
+	chapterStart int
+	chapterDue   bool
+}
+
+// maxResample limits how many times a rejected sentence is resampled before
+// being accepted anyway.
+const maxResample = 20
+
+func (bg *bodyGen) bias(sym symbol.Symbol, w uint) float64 {
+	if bg.need[sym] {
+		return includeBoost * float64(w)
+	}
+	if bg.mustGoOn() {
+		switch sym {
+		case 0, symbol.EOF:
+			return 0
+		}
+	}
+	return float64(w) * bg.g.length.bias(sym, bg.words)
+}
+
+// mustGoOn returns true if hard constraints are still unsatisfied.
+func (bg *bodyGen) mustGoOn() bool {
+	return bg.g.bodyCons.Hard && len(bg.need) > 0
+}
+
+// over returns true if the body should end after the current paragraph.
+func (bg *bodyGen) over() bool {
+	return !bg.ending && bg.g.length.over(bg.words) && !bg.mustGoOn()
+}
+
+// overrun returns true if the body should end after the current sentence,
+// since its final paragraph has run on too long.
+func (bg *bodyGen) overrun() bool {
+	return bg.over() && bg.g.length.overrun(bg.words)
+}
+
+// end ends the body with any End constraint words, bridging into them from
+// the current sentence.
+func (bg *bodyGen) end() error {
+	end := bg.g.bodyCons.End
+	if len(end) == 0 {
+		return errStop
+	}
+	bg.ending = true
+	esyms, err := bg.lng.Symbolize(end)
+	if err != nil {
+		return err
+	}
+	rev := bg.lng.Reversed()
+	for i := 0; i < fillAttempts; i++ {
+		bridge, ok := model.GenFill(bg.g.rng, bg.lng.Trans, rev.Trans, bg.last, esyms[0], maxBodyFill)
+		if !ok {
+			continue
+		}
+		for _, sym := range append(bridge, esyms...) {
+			// never reject the ending
+			bg.tries = maxResample
+			if err := bg.next(sym); err == errStop {
+				// e.g. an EOF within the bridge
+				break
+			} else if err != nil {
+				return err
+			}
+		}
+		return errStop
+	}
+	return errCantFill
+}
+
+func (bg *bodyGen) seed() error {
+	syms, err := bg.lng.Symbolize(bg.g.bodyCons.Start)
+	if err != nil {
+		return err
+	}
+	for _, sym := range syms {
+		// never reject seeded sentences
+		bg.tries = maxResample
+		if err := bg.next(sym); err != nil && err != errStop {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bg *bodyGen) run() error {
+	bg.chapter = 1
+	if err := bg.emit(Event{Type: EventChapter, N: bg.chapter}); err != nil {
+		return err
+	}
+	if err := bg.seed(); err != nil {
+		return err
+	}
+	for {
+		sym := bg.lng.Trans.Choose(bg.g.rng, bg.last, bg.bias)
+		if err := bg.next(sym); err == errStop {
+			break
+		} else if err != nil {
+			return err
+		}
+		if bg.mustGoOn() && bg.words > 2*bg.g.length.Max {
+			return errUnsatisfied
+		}
+	}
+	// NOTE: a rejected final sentence is simply dropped
+	if _, err := bg.endSentence(); err != nil {
+		return err
+	}
+	if err := bg.endPara(); err != nil {
+		return err
+	}
+	if bg.mustGoOn() {
+		return errUnsatisfied
+	}
+	for sym := range bg.need {
+		bg.stats.MissingWords = append(bg.stats.MissingWords, bg.lng.Dict.ToString(sym))
+	}
+	return bg.emit(Event{Type: EventEnd, N: bg.words})
+}
+
+func (bg *bodyGen) next(sym symbol.Symbol) error {
+	last := bg.last
+	bg.last = sym
+	switch sym {
+	case 0, symbol.EOF:
+		if bg.ending {
+			return errStop
+		}
+		bg.last = last
+		return bg.end()
+	case symbol.GS:
+		if err := bg.endPara(); err != nil {
+			return err
+		}
+		if !bg.inPara && bg.over() {
+			// end the body with this paragraph
+			return bg.end()
+		}
+		return nil
+	}
+
+	if len(bg.sent) == 0 {
+		bg.sentLast = last
+	}
+	word := bg.lng.Dict.ToString(sym)
+	if word == model.LineBreak {
+		// held with the sentence, but not counted as a word
+		bg.sent = append(bg.sent, Event{Type: EventLine, Symbol: sym, N: bg.words})
+		return nil
+	}
+	bg.words++
+	ev := Event{Type: EventWord, Symbol: sym, Word: word, N: bg.words}
+	if bg.lng.Parts != nil {
+		ev.Sources = bg.lng.Sources(last, sym)
+	}
+	bg.sent = append(bg.sent, ev)
+
+	switch word {
+	case ".", "!", "?":
+		if ok, err := bg.endSentence(); err != nil || !ok {
+			return err
+		}
+		if bg.overrun() {
+			// cut the final paragraph short at this sentence
+			return bg.end()
+		}
+	}
+	return nil
+}
+
+// endSentence emits any pending sentence, returning false if it was rejected
+// instead, rewinding generation to the start of the sentence.
+func (bg *bodyGen) endSentence() (bool, error) {
+	if len(bg.sent) == 0 {
+		return true, nil
+	}
+	words := 0
+	for _, ev := range bg.sent {
+		if ev.Type == EventWord {
+			words++
+		}
+	}
+	if !bg.accept() {
+		bg.last = bg.sentLast
+		bg.words -= words
+		bg.sent = bg.sent[:0]
+		return false, nil
+	}
+	if bg.chapterDue {
+		bg.chapterDue = false
+		bg.chapter++
+		bg.chapterStart = bg.words - words
+		if err := bg.emit(Event{Type: EventChapter, N: bg.chapter}); err != nil {
+			return true, err
+		}
+	}
+	for _, ev := range bg.sent {
+		delete(bg.need, ev.Symbol)
+		if err := bg.emit(ev); err != nil {
+			return true, err
+		}
+	}
+	bg.sent = bg.sent[:0]
+	bg.inPara = true
+	if words == 0 {
+		// e.g. just the line break after a sentence ending a line
+		return true, nil
+	}
+	return true, bg.emit(Event{Type: EventSentenceEnd, N: bg.words})
+}
+
+func (bg *bodyGen) accept() bool {
+	if bg.grams == nil {
+		return true
+	}
+	words := append(bg.recent[:len(bg.recent):len(bg.recent)], bg.sent...)
+	n, id, start := longestRun(bg.grams, words, len(bg.recent))
+	if n > bg.g.maxVerbatim && bg.tries < maxResample {
+		bg.tries++
+		bg.stats.VerbatimRejects++
+		return false
+	}
+	bg.tries = 0
+	if n > bg.stats.LongestVerbatim {
+		bg.stats.LongestVerbatim = n
+		bg.stats.LongestVerbatimSource = id
+		bg.stats.LongestVerbatimOffset = words[start].N
+	}
+	bg.recent = bg.recent[:0]
+	for _, ev := range words {
+		if ev.Type == EventWord {
+			bg.recent = append(bg.recent, ev)
+		}
+	}
+	if n := len(bg.recent) - bg.g.maxVerbatim; n > 0 {
+		bg.recent = append(bg.recent[:0], bg.recent[n:]...)
+	}
+	return true
+}
+
+func (bg *bodyGen) endPara() error {
+	if ok, err := bg.endSentence(); err != nil || !ok {
+		return err
+	}
+	if !bg.inPara {
+		return nil
+	}
+	bg.inPara = false
+	bg.recent = bg.recent[:0]
+	if n := bg.g.chapterLen; n > 0 && bg.words-bg.chapterStart >= n {
+		bg.chapterDue = true
+	}
+	return bg.emit(Event{Type: EventParagraph, N: bg.words})
+}
+
+func (g gen) GenEvents(docs model.SupportDocIDs, f func(Event) error) error {
+	lng, err := g.mergedLang(docs)
+	if err != nil {
+		return err
+	}
+	return g.genBody(docs, lng, f)
+}
+
+func (g gen) mergedLang(docs model.SupportDocIDs) (model.MergedLang, error) {
+	if g.verse {
+		if g.provenance == nil {
+			lng, err := g.db.MergedDocVerse(docs)
+			return model.MergedLang{Lang: lng}, err
+		}
+		return g.db.MergedVerseSources(docs)
+	}
+	if g.provenance == nil {
+		lng, err := g.db.MergedDocLang(docs)
+		return model.MergedLang{Lang: lng}, err
+	}
+	return g.db.MergedDocSources(docs)
+}
+
+func (g gen) genBody(docs model.SupportDocIDs, lng model.MergedLang, f func(Event) error) error {
+	bg := bodyGen{g: g, lng: lng, stats: g.stats, emit: f}
+	need, unknown, err := g.bodyCons.needs(lng.Lang)
+	if err != nil {
+		return err
+	}
+	bg.need = need
+	bg.stats.MissingWords = append(bg.stats.MissingWords, unknown...)
+	if g.maxVerbatim > 0 {
+		grams, err := g.verbatimIndex(docs)
+		if err != nil {
+			return err
+		}
+		bg.grams = grams
+	}
+	return bg.run()
+}
@@ -0,0 +1,73 @@
+package gen
+
+import (
+	"errors"
+
+	"github.com/jcorbin/markov/internal/model"
+	"github.com/jcorbin/markov/internal/symbol"
+)
+
+var errCantFill = errors.New("unable to fill between words")
+
+const (
+	// fillAttempts limits how many times a fill is retried.
+	fillAttempts = 100
+
+	// maxTitleFill and maxBodyFill limit the number of generated words that
+	// may bridge between two title or body words.
+	maxTitleFill = 10
+	maxBodyFill  = 50
+)
+
+// cache holds state built on demand, shared by copies of a gen.
+type cache struct {
+	titleRev   *model.Lang
+	mashUpPool []string
+	existing   titleIndex
+}
+
+func (g gen) titleRev() model.Lang {
+	if g.cache.titleRev == nil {
+		rev := g.db.TitleLang.Reversed()
+		g.cache.titleRev = &rev
+	}
+	return *g.cache.titleRev
+}
+
+func (g gen) FillTitle(from, to string) ([]string, error) {
+	return g.fill(g.db.TitleLang, g.titleRev(), from, to, maxTitleFill)
+}
+
+func (g gen) Fill(docs model.SupportDocIDs, from, to string) ([]string, error) {
+	lng, err := g.db.MergedDocLang(docs)
+	if err != nil {
+		return nil, err
+	}
+	return g.fill(lng, lng.Reversed(), from, to, maxBodyFill)
+}
+
+func (g gen) fill(lng, rev model.Lang, from, to string, maxLen int) ([]string, error) {
+	var ends [2]symbol.Symbol
+	for i, word := range []string{from, to} {
+		if word == "" {
+			continue
+		}
+		syms, err := lng.Symbolize([]string{word})
+		if err != nil {
+			return nil, err
+		}
+		ends[i] = syms[0]
+	}
+	for i := 0; i < fillAttempts; i++ {
+		syms, ok := model.GenFill(g.rng, lng.Trans, rev.Trans, ends[0], ends[1], maxLen)
+		if !ok {
+			continue
+		}
+		words := make([]string, len(syms))
+		for i, sym := range syms {
+			words[i] = lng.Dict.ToString(sym)
+		}
+		return words, nil
+	}
+	return nil, errCantFill
+}
@@ -18,18 +18,187 @@ type Gen interface {
 	// on a given title and supporting document ID set. Any io error
 	// encountered while writing halts the process, and is returned.
 	GenBook(title string, docs model.SupportDocIDs, w io.Writer) error
+
+	// RenderBook is like GenBook, but passes structured book events to the
+	// given Renderer, which it closes before returning.
+	RenderBook(title string, docs model.SupportDocIDs, r Renderer) error
+
+	// GenEvents generates book body content from the given supporting
+	// document ID set, calling f with each generation event; any error
+	// returned by f halts generation, and is returned.
+	GenEvents(docs model.SupportDocIDs, f func(Event) error) error
+
+	// FillTitle generates title words bridging from one word to another;
+	// either may be empty, meaning the start or end of a title.
+	FillTitle(from, to string) ([]string, error)
+
+	// Fill generates body words bridging from one word to another, from the
+	// language of the given supporting document ID set; either may be
+	// empty, meaning the start or end of a document.
+	Fill(docs model.SupportDocIDs, from, to string) ([]string, error)
 }
 
 // New constructs a Gen that will generate from a database of extracted
-// documents.
-func New(db model.DocDB) Gen {
-	return gen{
-		db:  db,
-		rng: rand.New(rand.NewSource(rand.Int63())),
+// documents; fails if the options are invalid, e.g. an inverted Length, or a
+// Restriction that matches no documents.
+func New(db model.DocDB, opts ...Option) (Gen, error) {
+	g := gen{
+		db:          db,
+		rng:         rand.New(rand.NewSource(rand.Int63())),
+		length:      DefaultLength,
+		chapterLen:  DefaultChapterLength,
+		titlePolicy: DefaultTitlePolicy,
+		stats:       &Stats{},
+		cache:       &cache{},
+	}
+	for _, opt := range opts {
+		opt(&g)
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	if err := g.length.Validate(); err != nil {
+		return nil, err
+	}
+	author, other, err := g.restriction.sets(g.db)
+	if err != nil {
+		return nil, err
+	}
+	g.authorSet, g.otherSets = author, other
+	g.db.TitleLang = g.restriction.titleLang(g.db)
+	return g, nil
+}
+
+// Option customizes a Gen constructed by New.
+type Option func(*gen)
+
+// WithTitlePolicy sets the policy by which GenTitle accepts titles.
+func WithTitlePolicy(tp TitlePolicy) Option {
+	return func(g *gen) { g.titlePolicy = tp }
+}
+
+// WithSupportSelection caps the number of supporting documents for a
+// generated title, choosing them by the given ranking; see
+// model.SupportDocIDs.Select.
+func WithSupportSelection(max int, rank model.Ranking) Option {
+	return func(g *gen) {
+		g.maxSupport = max
+		g.supportRank = rank
+	}
+}
+
+// WithRestriction restricts supporting documents, e.g. to generate a book in
+// the style of an author, or in a single language; titles are generated from
+// the author's or subject's title language, if the DocDB has one.
+func WithRestriction(r Restriction) Option {
+	return func(g *gen) { g.restriction = r }
+}
+
+// WithLength sets the target body length range.
+func WithLength(l Length) Option {
+	return func(g *gen) { g.length = l }
+}
+
+// DefaultChapterLength is the chapter length used unless overridden by
+// WithChapterLength.
+const DefaultChapterLength = 2500
+
+// WithChapterLength starts a new chapter at the first paragraph end after
+// every this many body words; zero keeps the whole body in one chapter.
+func WithChapterLength(words int) Option {
+	return func(g *gen) { g.chapterLen = words }
+}
+
+// WithColophon sets whether a closing colophon is written after the body.
+func WithColophon(colophon bool) Option {
+	return func(g *gen) { g.colophon = colophon }
+}
+
+// WithProvenance enables tracking which supporting documents contributed
+// each generated word; RenderBook writes a JSON sidecar mapping word offsets
+// to supporting documents to the given writer.
+func WithProvenance(w io.Writer) Option {
+	return func(g *gen) { g.provenance = w }
+}
+
+// WithVerbatimLimit limits how many consecutive words may be copied verbatim
+// from any one supporting document; sentences exceeding the limit are
+// resampled. Supporting document text is loaded by the given TokenSource.
+func WithVerbatimLimit(max int, src TokenSource) Option {
+	return func(g *gen) {
+		g.maxVerbatim = max
+		g.tokens = src
+	}
+}
+
+// WithVerse generates verse, from the verse languages of the supporting
+// documents, rather than prose: body lines are ended by EventLine, and
+// paragraphs are stanzas.
+func WithVerse(verse bool) Option {
+	return func(g *gen) { g.verse = verse }
+}
+
+// WithStats sets a Stats that generation will update.
+func WithStats(stats *Stats) Option {
+	return func(g *gen) { g.stats = stats }
+}
+
+// WithTitleConstraints constrains generated titles.
+func WithTitleConstraints(c Constraints) Option {
+	return func(g *gen) { g.titleCons = c }
+}
+
+// WithBodyConstraints constrains generated body text; body inclusion is only
+// guided, and checked once the body is done, except that a Hard constraint
+// will extend the body (up to twice its maximum length) until satisfied.
+func WithBodyConstraints(c Constraints) Option {
+	return func(g *gen) { g.bodyCons = c }
+}
+
+// WithMashUps makes GenTitle fill in a random mash-up template, rather than
+// chaining a title, with the given probability; if no templates are given,
+// model.DefaultMashUps are used. Titles are mashed up from those of documents
+// satisfying any Restriction, preferring ones with title Include words; title
+// Start or End constraints disable mash-ups. New fails if any template is
+// invalid.
+func WithMashUps(prob float64, tmpls ...model.MashUp) Option {
+	for _, tmpl := range tmpls {
+		if err := tmpl.Validate(); err != nil {
+			return func(g *gen) { g.err = err }
+		}
+	}
+	return func(g *gen) {
+		if len(tmpls) == 0 {
+			tmpls = model.DefaultMashUps
+		}
+		g.mashUp = prob
+		g.mashUps = tmpls
 	}
 }
 
 type gen struct {
-	db  model.DocDB
-	rng *rand.Rand
+	db          model.DocDB
+	rng         *rand.Rand
+	length      Length
+	chapterLen  int
+	colophon    bool
+	verse       bool
+	provenance  io.Writer
+	maxVerbatim int
+	tokens      TokenSource
+	stats       *Stats
+	titleCons   Constraints
+	bodyCons    Constraints
+	titlePolicy TitlePolicy
+	restriction Restriction
+	authorSet   map[string]bool
+	otherSets   []map[string]bool
+	maxSupport  int
+	supportRank model.Ranking
+	mashUp      float64
+	mashUps     []model.MashUp
+	cache       *cache
+
+	// err is any error building an Option, returned by New
+	err error
 }
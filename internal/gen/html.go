@@ -0,0 +1,123 @@
+package gen
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+)
+
+type htmlRenderer struct {
+	w       *bufio.Writer
+	lang    string
+	para    bool
+	brk     bool
+	chapter bool
+}
+
+// NewHTMLRenderer creates a Renderer that writes a standalone HTML document.
+func NewHTMLRenderer(w io.Writer) Renderer {
+	return &htmlRenderer{w: bufio.NewWriter(w)}
+}
+
+func (hr *htmlRenderer) Language(code string) error {
+	hr.lang = code
+	return nil
+}
+
+func (hr *htmlRenderer) Title(title string) error {
+	title = html.EscapeString(title)
+	_, err := fmt.Fprintf(hr.w, `<!DOCTYPE html>
+<html lang="%s">
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+</head>
+<body>
+<h1>%s</h1>
+`, html.EscapeString(hr.lang), title, title)
+	return err
+}
+
+func (hr *htmlRenderer) SupportDocs(names []string) error {
+	if _, err := hr.w.WriteString("<section class=\"support\">\n<h2>Supporting Docs</h2>\n<ul>\n"); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(hr.w, "<li>%s</li>\n", html.EscapeString(name)); err != nil {
+			return err
+		}
+	}
+	_, err := hr.w.WriteString("</ul>\n</section>\n")
+	return err
+}
+
+func (hr *htmlRenderer) Chapter(n int) error {
+	if err := hr.endChapter(); err != nil {
+		return err
+	}
+	hr.chapter = true
+	_, err := fmt.Fprintf(hr.w, "<section class=\"chapter\">\n<h2>Chapter %d</h2>\n", n)
+	return err
+}
+
+func (hr *htmlRenderer) endChapter() error {
+	if !hr.chapter {
+		return nil
+	}
+	hr.chapter = false
+	_, err := hr.w.WriteString("</section>\n")
+	return err
+}
+
+func (hr *htmlRenderer) Sentence(words []string) error {
+	if hr.brk {
+		_, _ = hr.w.WriteString("<br/>\n")
+		hr.brk = false
+	} else if hr.para {
+		_ = hr.w.WriteByte(' ')
+	} else {
+		_, _ = hr.w.WriteString("<p>")
+		hr.para = true
+	}
+	_, err := hr.w.WriteString(html.EscapeString(sentenceText(words)))
+	return err
+}
+
+func (hr *htmlRenderer) LineBreak() error {
+	hr.brk = hr.para
+	return nil
+}
+
+func (hr *htmlRenderer) EndParagraph() error {
+	if !hr.para {
+		return nil
+	}
+	hr.para, hr.brk = false, false
+	_, err := hr.w.WriteString("</p>\n")
+	return err
+}
+
+func (hr *htmlRenderer) Colophon(text string) error {
+	if err := hr.EndParagraph(); err != nil {
+		return err
+	}
+	if err := hr.endChapter(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(hr.w, "<p class=\"colophon\">%s</p>\n", html.EscapeString(text))
+	return err
+}
+
+func (hr *htmlRenderer) Close() error {
+	if err := hr.EndParagraph(); err != nil {
+		return err
+	}
+	if err := hr.endChapter(); err != nil {
+		return err
+	}
+	if _, err := hr.w.WriteString("</body>\n</html>\n"); err != nil {
+		return err
+	}
+	return hr.w.Flush()
+}
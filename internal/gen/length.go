@@ -0,0 +1,68 @@
+package gen
+
+import (
+	"fmt"
+
+	"github.com/jcorbin/markov/internal/symbol"
+)
+
+// Length is a target body length range, in words; generation will not end
+// before Min words, becomes increasingly biased towards paragraph and
+// document ends as it approaches Max, and ends at the first paragraph end
+// after Max; a paragraph that runs on past Max by more than a quarter is cut
+// short at its next sentence end.
+type Length struct {
+	Min int
+	Max int
+}
+
+// Validate returns an error if the range is negative or inverted.
+func (l Length) Validate() error {
+	if l.Min < 0 || l.Max < l.Min {
+		return fmt.Errorf("invalid body length range: min %d, max %d", l.Min, l.Max)
+	}
+	return nil
+}
+
+// DefaultLength is the Length used unless overridden by WithLength.
+var DefaultLength = Length{Min: 5000, Max: 10000}
+
+// endBoost is the factor by which ending transitions are boosted once Max
+// words have been generated.
+const endBoost = 100
+
+// bias returns the multiplier for the weight of transitioning to sym after n
+// words have been generated.
+func (l Length) bias(sym symbol.Symbol, n int) float64 {
+	switch sym {
+	case 0, symbol.EOF:
+		if n < l.Min {
+			return 0
+		}
+	case symbol.GS:
+		if n < l.Min {
+			return 1
+		}
+	default:
+		return 1
+	}
+	if l.Max <= l.Min {
+		return endBoost
+	}
+	p := float64(n-l.Min) / float64(l.Max-l.Min)
+	if p > 1 {
+		p = 1
+	}
+	return 1 + (endBoost-1)*p*p
+}
+
+// over returns true if n words exceeds the target.
+func (l Length) over(n int) bool {
+	return n >= l.Max
+}
+
+// overrun returns true if n words exceeds the target by so much that the
+// final paragraph should be cut short.
+func (l Length) overrun(n int) bool {
+	return n >= l.Max+l.Max/4
+}
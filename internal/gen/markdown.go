@@ -0,0 +1,90 @@
+package gen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`#`, `\#`,
+)
+
+type markdownRenderer struct {
+	w    *bufio.Writer
+	para bool
+	brk  bool
+}
+
+// NewMarkdownRenderer creates a Renderer that writes Markdown, with one line
+// per paragraph.
+func NewMarkdownRenderer(w io.Writer) Renderer {
+	return &markdownRenderer{w: bufio.NewWriter(w)}
+}
+
+func (mr *markdownRenderer) Language(code string) error { return nil }
+
+func (mr *markdownRenderer) Title(title string) error {
+	_, err := fmt.Fprintf(mr.w, "# %s\n\n", markdownEscaper.Replace(title))
+	return err
+}
+
+func (mr *markdownRenderer) SupportDocs(names []string) error {
+	if _, err := fmt.Fprintf(mr.w, "## Supporting Docs\n\n"); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(mr.w, "- %s\n", markdownEscaper.Replace(name)); err != nil {
+			return err
+		}
+	}
+	_, err := mr.w.WriteString("\n")
+	return err
+}
+
+func (mr *markdownRenderer) Chapter(n int) error {
+	_, err := fmt.Fprintf(mr.w, "## Chapter %d\n\n", n)
+	return err
+}
+
+func (mr *markdownRenderer) Sentence(words []string) error {
+	switch {
+	case mr.brk:
+		// a hard line break, only written between lines
+		_, _ = mr.w.WriteString("\\\n")
+		mr.brk = false
+	case mr.para:
+		_ = mr.w.WriteByte(' ')
+	}
+	mr.para = true
+	_, err := mr.w.WriteString(markdownEscaper.Replace(sentenceText(words)))
+	return err
+}
+
+func (mr *markdownRenderer) LineBreak() error {
+	mr.brk = mr.para
+	return nil
+}
+
+func (mr *markdownRenderer) EndParagraph() error {
+	mr.para, mr.brk = false, false
+	_, err := mr.w.WriteString("\n\n")
+	return err
+}
+
+func (mr *markdownRenderer) Colophon(text string) error {
+	_, err := fmt.Fprintf(mr.w, "*%s*\n", markdownEscaper.Replace(text))
+	return err
+}
+
+func (mr *markdownRenderer) Close() error {
+	return mr.w.Flush()
+}
@@ -0,0 +1,82 @@
+package gen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Renderer receives structured book events from Gen.RenderBook, writing them
+// out in some format. Any slice passed to a Renderer is only valid for the
+// duration of the call.
+type Renderer interface {
+	// Language is called first, with the book's language code, e.g. "en".
+	Language(code string) error
+
+	// Title is called after Language, with the book title.
+	Title(title string) error
+
+	// SupportDocs is called after Title with the supporting document names
+	// (see model.DocDB.DocName), most relevant first.
+	SupportDocs(names []string) error
+
+	// Chapter starts the n-th chapter, counting from 1.
+	Chapter(n int) error
+
+	// Sentence adds a sentence of words, including any punctuation tokens, to
+	// the current paragraph.
+	Sentence(words []string) error
+
+	// LineBreak ends the current line of verse; a sentence may span lines,
+	// in which case each line's part of it is passed to Sentence.
+	LineBreak() error
+
+	// EndParagraph ends the current paragraph, or stanza of verse.
+	EndParagraph() error
+
+	// Colophon adds closing text after the body, e.g. "THE END".
+	Colophon(text string) error
+
+	// Close finishes rendering, flushing any buffered output.
+	Close() error
+}
+
+// Formats lists the names accepted by NewRenderer.
+var Formats = []string{"text", "markdown", "html", "epub"}
+
+// NewRenderer creates a Renderer for the named format, writing to w.
+func NewRenderer(format string, w io.Writer) (Renderer, error) {
+	switch format {
+	case "", "text":
+		return NewTextRenderer(w), nil
+	case "markdown", "md":
+		return NewMarkdownRenderer(w), nil
+	case "html":
+		return NewHTMLRenderer(w), nil
+	case "epub":
+		return NewEPUBRenderer(w), nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected one of %q", format, Formats)
+}
+
+// sentenceText joins the words of a sentence, capitalizing the first word.
+func sentenceText(words []string) string {
+	var buf strings.Builder
+	for i, word := range words {
+		if i == 0 {
+			word = strings.Title(word)
+		} else {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(word)
+	}
+	return buf.String()
+}
+
+// center left pads s with space so that it's centered within width.
+func center(s string, width int) string {
+	if n := (width - len(s)) / 2; n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
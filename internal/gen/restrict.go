@@ -0,0 +1,112 @@
+package gen
+
+import (
+	"fmt"
+
+	"github.com/jcorbin/markov/internal/model"
+)
+
+// Restriction restricts supporting documents to those by an author, in a
+// language, or about a subject; empty fields are unrestricted.
+type Restriction struct {
+	// Author restricts supporting documents to those by the author; if a
+	// title has no supporting documents by the author, all of the author's
+	// documents support it instead, so that the book is written in the
+	// author's style.
+	Author string
+
+	Language string
+	Subject  string
+}
+
+// sets returns the id set for the Author, if any, and those for the other
+// restricting fields; fails if any field matches no documents.
+func (r Restriction) sets(db model.DocDB) (author map[string]bool, other []map[string]bool, err error) {
+	for _, field := range []struct {
+		name, key string
+		idx       model.DocIndex
+	}{
+		{"author", r.Author, db.Authors},
+		{"language", r.Language, db.Languages},
+		{"subject", r.Subject, db.Subjects},
+	} {
+		if field.key == "" {
+			continue
+		}
+		ids := field.idx.Lookup(field.key)
+		if len(ids) == 0 {
+			return nil, nil, fmt.Errorf("no documents with %s %q", field.name, field.key)
+		}
+		set := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			set[id] = true
+		}
+		if field.name == "author" {
+			author = set
+		} else {
+			other = append(other, set)
+		}
+	}
+	return author, other, nil
+}
+
+// titleLang returns the author's title language, else the subject's, if the
+// DocDB has one; otherwise it returns the DocDB's TitleLang.
+func (r Restriction) titleLang(db model.DocDB) model.Lang {
+	if lng, ok := db.AuthorTitleLangs[model.IndexKey(r.Author)]; ok && r.Author != "" {
+		return lng
+	}
+	if lng, ok := db.SubjectTitleLangs[model.IndexKey(r.Subject)]; ok && r.Subject != "" {
+		return lng
+	}
+	return db.TitleLang
+}
+
+// restrict filters supporting documents, returning nil if none remain; it
+// also returns true if it fell back to all of the author's documents.
+func (g gen) restrict(docs model.SupportDocIDs) (model.SupportDocIDs, bool) {
+	sets := g.restrictSets()
+	if len(sets) == 0 {
+		return docs, false
+	}
+
+	out := make(model.SupportDocIDs, len(docs))
+	for id, sd := range docs {
+		if inAll(id, sets) {
+			out[id] = sd
+		}
+	}
+
+	fallback := false
+	if len(out) == 0 && g.authorSet != nil {
+		// in the style of the author
+		fallback = true
+		for id := range g.authorSet {
+			if inAll(id, g.otherSets) {
+				out[id] = model.SupportDoc{Reason: fmt.Sprintf("by author %q", g.restriction.Author)}
+			}
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, false
+	}
+	return out, fallback
+}
+
+// restrictSets returns all of the restricting id sets.
+func (g gen) restrictSets() []map[string]bool {
+	if g.authorSet == nil {
+		return g.otherSets
+	}
+	return append([]map[string]bool{g.authorSet}, g.otherSets...)
+}
+
+func inAll(id string, sets []map[string]bool) bool {
+	for _, set := range sets {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
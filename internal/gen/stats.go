@@ -0,0 +1,42 @@
+package gen
+
+// Stats collects generation statistics.
+type Stats struct {
+	// TitleAttempts counts generated titles, not counting any empty ones,
+	// and TitleDuplicates those that duplicated an existing title, whether
+	// or not they were otherwise acceptable; DuplicateOf is the existing title that the last title
+	// returned by GenTitle duplicates, if any.
+	TitleAttempts   int
+	TitleDuplicates int
+	DuplicateOf     string
+
+	// AuthorFallback is true if none of the last title's own supporting
+	// documents were by the restricted author, so that all of the author's
+	// documents support it instead.
+	AuthorFallback bool
+
+	// VerbatimRejects counts sentences resampled for exceeding the verbatim
+	// limit.
+	VerbatimRejects int
+
+	// LongestVerbatim is the longest run of generated words copied from a
+	// single supporting document, starting at word offset
+	// LongestVerbatimOffset; only runs of at least verbatimGram words are
+	// detected.
+	LongestVerbatim       int
+	LongestVerbatimSource string
+	LongestVerbatimOffset int
+
+	// MissingWords lists any words preferred by body Constraints that
+	// didn't get generated.
+	MissingWords []string
+}
+
+// DuplicateRate returns the fraction of generated titles that duplicated an
+// existing title.
+func (st Stats) DuplicateRate() float64 {
+	if st.TitleAttempts == 0 {
+		return 0
+	}
+	return float64(st.TitleDuplicates) / float64(st.TitleAttempts)
+}
@@ -0,0 +1,92 @@
+package gen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const lineWrap = 80 - 1
+
+type textRenderer struct {
+	lg lineGen
+}
+
+// NewTextRenderer creates a Renderer that writes plain text, hard-wrapped
+// at 80 columns.
+func NewTextRenderer(w io.Writer) Renderer {
+	tr := &textRenderer{lg: lineGen{w: w}}
+	tr.lg.buf.Grow(lineWrap + 2)
+	return tr
+}
+
+func (tr *textRenderer) Language(code string) error { return nil }
+
+func (tr *textRenderer) Title(title string) error {
+	_, err := fmt.Fprintf(tr.lg.w, "Title: %q\n", title)
+	return err
+}
+
+func (tr *textRenderer) SupportDocs(names []string) error {
+	if _, err := fmt.Fprintf(tr.lg.w, "\nSupporting Docs:\n"); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(tr.lg.w, "- %q\n", name); err != nil {
+			return err
+		}
+	}
+	_, err := tr.lg.w.Write([]byte("\n"))
+	return err
+}
+
+func (tr *textRenderer) Chapter(n int) error {
+	if err := tr.lg.flush(); err != nil {
+		return err
+	}
+	head := fmt.Sprintf("CHAPTER %d", n)
+	_, err := fmt.Fprintf(tr.lg.w, "%s\n\n", center(head, lineWrap))
+	return err
+}
+
+func (tr *textRenderer) Sentence(words []string) error {
+	for i, word := range words {
+		if i == 0 {
+			word = strings.Title(word)
+		}
+		if err := tr.lg.flushIfExceeds(len(word), lineWrap); err != nil {
+			return err
+		}
+		if tr.lg.buf.Len() > 0 {
+			_, _ = tr.lg.buf.WriteRune(' ')
+		}
+		_, _ = tr.lg.buf.WriteString(word)
+	}
+	return nil
+}
+
+func (tr *textRenderer) LineBreak() error {
+	return tr.lg.flush()
+}
+
+func (tr *textRenderer) EndParagraph() error {
+	if tr.lg.buf.Len() == 0 {
+		// the last line of a stanza was already flushed
+		_, err := io.WriteString(tr.lg.w, "\n")
+		return err
+	}
+	tr.lg.buf.WriteRune('\n')
+	return tr.lg.flush()
+}
+
+func (tr *textRenderer) Colophon(text string) error {
+	if err := tr.lg.flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(tr.lg.w, "%s\n", center(text, lineWrap))
+	return err
+}
+
+func (tr *textRenderer) Close() error {
+	return tr.lg.flush()
+}
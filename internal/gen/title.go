@@ -2,29 +2,180 @@ package gen
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/jcorbin/markov/internal/model"
 )
 
 var errCantEvenTitle = errors.New("unable to produce acceptable title")
 
+// TitlePolicy determines which generated titles GenTitle accepts; zero
+// maximums are unlimited.
+type TitlePolicy struct {
+	// Attempts is how many titles to generate before giving up; GenTitle
+	// always fails if it's zero.
+	Attempts int
+
+	// MinWords and MaxWords bound the number of words in a title.
+	MinWords int
+	MaxWords int
+
+	// MinSupportWordLength is the minimum length of a supporting word;
+	// supporting documents with shorter words are dropped.
+	MinSupportWordLength int
+
+	// MinSupportDocs and MaxSupportDocs bound the number of supporting
+	// documents, rejecting titles outside them; to instead generate from a
+	// subset of a title's supporting documents, see WithSupportSelection.
+	MinSupportDocs int
+	MaxSupportDocs int
+
+	// RejectExisting rejects titles that duplicate an existing document
+	// title, after case folding and stripping punctuation; otherwise
+	// duplicates are only counted in Stats.
+	RejectExisting bool
+
+	// NearDistance, if positive, also treats titles within this many
+	// character edits of an existing title as duplicates.
+	NearDistance int
+}
+
+// DefaultTitlePolicy is the TitlePolicy used unless overridden by
+// WithTitlePolicy; it's tuned for a full Project Gutenberg DocDB.
+var DefaultTitlePolicy = TitlePolicy{
+	Attempts:             100,
+	MinSupportWordLength: 4,
+	MinSupportDocs:       10,
+}
+
+// accept returns true if the title is acceptable, and its supporting docs
+// less any supported by short words, leaving docs as is; isDup is whether the
+// title duplicates an existing title.
+func (tp TitlePolicy) accept(title string, docs model.SupportDocIDs, isDup bool) (model.SupportDocIDs, bool) {
+	if n := len(strings.Fields(title)); n < tp.MinWords || (tp.MaxWords > 0 && n > tp.MaxWords) {
+		return nil, false
+	}
+	if isDup && tp.RejectExisting {
+		return nil, false
+	}
+	kept := make(model.SupportDocIDs, len(docs))
+	for id, sd := range docs {
+		if len(sd.Word) >= tp.MinSupportWordLength {
+			kept[id] = sd
+		}
+	}
+	if n := len(kept); n < tp.MinSupportDocs || (tp.MaxSupportDocs > 0 && n > tp.MaxSupportDocs) {
+		return nil, false
+	}
+	return kept, true
+}
+
 func (g gen) GenTitle() (string, model.SupportDocIDs, error) {
-	const (
-		numAttempts          = 100
-		minSupportWordLength = 3
-		minSupportDocs       = 9
+	var (
+		bias     = g.titleCons.titleBias(g.db.TitleLang)
+		best     string
+		bestDocs model.SupportDocIDs
+		bestDup  string
+		bestFell bool
+		bestMiss = len(g.titleCons.Include) + 1
 	)
+	g.stats.DuplicateOf = ""
+	g.stats.AuthorFallback = false
 
-	for i := 0; i < numAttempts; i++ {
-		title, docs := g.db.GenTitle(g.rng)
-		for id, word := range docs {
-			if len(word) <= minSupportWordLength {
-				delete(docs, id)
-			}
+	for i := 0; i < g.titlePolicy.Attempts; i++ {
+		title, docs, err := g.genTitle(bias)
+		if err != nil {
+			return "", nil, err
 		}
-		if len(docs) > minSupportDocs {
+		if title == "" {
+			continue
+		}
+		g.stats.TitleAttempts++
+		dup, isDup := g.existingTitles().dupOf(title, g.titlePolicy.NearDistance)
+		if isDup {
+			g.stats.TitleDuplicates++
+		}
+		docs, ok := g.titlePolicy.accept(title, docs, isDup)
+		if !ok {
+			continue
+		}
+		docs, fell := g.restrict(docs)
+		if docs == nil {
+			continue
+		}
+		docs = docs.Select(g.db, g.maxSupport, g.supportRank)
+		miss := len(g.titleCons.titleMissing(title))
+		if miss == 0 {
+			g.stats.DuplicateOf, g.stats.AuthorFallback = dup, fell
 			return title, docs, nil
 		}
+		if !g.titleCons.Hard && miss < bestMiss {
+			best, bestDocs, bestDup, bestFell, bestMiss = title, docs, dup, fell, miss
+		}
+	}
+	if bestDocs != nil {
+		g.stats.DuplicateOf, g.stats.AuthorFallback = bestDup, bestFell
+		return best, bestDocs, nil
 	}
 	return "", nil, errCantEvenTitle
 }
+
+func (g gen) genTitle(bias model.Bias) (string, model.SupportDocIDs, error) {
+	if g.mashUp > 0 && len(g.titleCons.Start) == 0 && len(g.titleCons.End) == 0 &&
+		g.rng.Float64() < g.mashUp {
+		if pool := g.mashUpPool(); len(pool) > 0 {
+			tmpl := g.mashUps[g.rng.Intn(len(g.mashUps))]
+			return g.db.GenMashUpTitle(g.rng, tmpl, pool)
+		}
+	}
+	if len(g.titleCons.End) > 0 {
+		return g.db.GenTitleTo(g.rng, g.titleRev(), g.titleCons.Start, g.titleCons.End, maxTitleFill)
+	}
+	return g.db.GenTitleFrom(g.rng, g.titleCons.Start, bias)
+}
+
+// mashUpPool returns the ids of documents whose titles may be mashed up: those
+// satisfying the Restriction, narrowed to those whose titles have any title
+// Include words, unless none do.
+func (g gen) mashUpPool() []string {
+	if g.cache.mashUpPool != nil {
+		return g.cache.mashUpPool
+	}
+	sets := g.restrictSets()
+	pool := []string{}
+	for _, id := range g.db.TitleIDs() {
+		if inAll(id, sets) {
+			pool = append(pool, id)
+		}
+	}
+	if len(g.titleCons.Include) > 0 {
+		inc := make(map[string]bool)
+		for _, word := range g.titleCons.Include {
+			for _, id := range g.db.InvTW[word] {
+				inc[id] = true
+			}
+		}
+		var with []string
+		for _, id := range pool {
+			if inc[id] {
+				with = append(with, id)
+			}
+		}
+		if len(with) > 0 {
+			pool = with
+		}
+	}
+	g.cache.mashUpPool = pool
+	return pool
+}
+
+func (g gen) existingTitles() titleIndex {
+	if g.cache.existing.exact == nil {
+		titles := make([]string, 0, len(g.db.Docs))
+		for _, di := range g.db.Docs {
+			titles = append(titles, di.Title)
+		}
+		g.cache.existing = newTitleIndex(titles)
+	}
+	return g.cache.existing
+}
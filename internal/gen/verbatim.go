@@ -0,0 +1,83 @@
+package gen
+
+import "github.com/jcorbin/markov/internal/model"
+
+// TokenSource loads the normalized body tokens of a document, with "" tokens
+// marking paragraph ends.
+type TokenSource func(di model.DocInfo) ([]string, error)
+
+// verbatimGram is the size of sequences indexed to detect verbatim runs.
+const verbatimGram = 3
+
+func (g gen) verbatimIndex(docs model.SupportDocIDs) (*model.NGramIndex, error) {
+	n := verbatimGram
+	if n > g.maxVerbatim+1 {
+		n = g.maxVerbatim + 1
+	}
+	grams := model.NewNGramIndex(n)
+	for _, id := range docs.SortedIDs() {
+		toks, err := g.tokens(g.db.Docs[id])
+		if err != nil {
+			return nil, err
+		}
+		grams.Add(id, toks)
+	}
+	return grams, nil
+}
+
+// longestRun returns the longest run of words copied from any one document,
+// that document's id, and the index of its first word; only runs that end at
+// or after words[from] are considered, earlier words only providing context.
+func longestRun(grams *model.NGramIndex, words []Event, from int) (best int, bestID string, bestStart int) {
+	var (
+		run    int
+		runIDs []string
+		at     []int // indices of word events
+	)
+	rh := model.NewRollingHash(grams.N)
+	for i, ev := range words {
+		if ev.Type != EventWord {
+			continue
+		}
+		at = append(at, i)
+		sum, full := rh.Push(ev.Word)
+		if !full {
+			continue
+		}
+		ids := grams.Sources(sum)
+		if len(ids) == 0 {
+			run, runIDs = 0, nil
+			continue
+		}
+		if run > 0 {
+			runIDs = intersect(runIDs, ids)
+		}
+		if len(runIDs) > 0 {
+			run++
+		} else {
+			run, runIDs = 1, ids
+		}
+		if n := run + grams.N - 1; i >= from && n > best {
+			best, bestID, bestStart = n, runIDs[0], at[len(at)-n]
+		}
+	}
+	return best, bestID, bestStart
+}
+
+// intersect returns the ids in both of two sorted lists.
+func intersect(a, b []string) []string {
+	var both []string
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			both = append(both, a[i])
+			i++
+			j++
+		}
+	}
+	return both
+}
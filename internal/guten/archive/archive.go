@@ -0,0 +1,97 @@
+// Package archive opens Project Gutenberg texts, which mirrors mostly
+// distribute as zip (or gzip) archives.
+package archive
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Members returns the paths of the text members in the named file: the .txt
+// files in a zip archive, sorted; a gzip or plain file has a single unnamed
+// member, "".
+func Members(name string) ([]string, error) {
+	if strings.ToLower(path.Ext(name)) != ".zip" {
+		return []string{""}, nil
+	}
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var members []string
+	for _, f := range zr.File {
+		if strings.ToLower(path.Ext(f.Name)) == ".txt" {
+			members = append(members, f.Name)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// Open opens a member of the named file, as returned by Members.
+func Open(name, member string) (io.ReadCloser, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".zip":
+		return openZip(name, member)
+	case ".gz":
+		return openGzip(name)
+	}
+	return os.Open(name)
+}
+
+func openZip(name, member string) (io.ReadCloser, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if f.Name != member {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			zr.Close()
+			return nil, err
+		}
+		return readCloser{r, closers{r, zr}}, nil
+	}
+	zr.Close()
+	return nil, fmt.Errorf("no member %q in %q", member, name)
+}
+
+func openGzip(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return readCloser{gr, closers{gr, f}}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// closers closes all of its elements, returning the first error.
+type closers []io.Closer
+
+func (cs closers) Close() (err error) {
+	for _, c := range cs {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
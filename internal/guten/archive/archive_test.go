@@ -0,0 +1,53 @@
+package archive
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	want, err := os.ReadFile("testdata/tiny.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name    string
+		members []string
+	}{
+		{"testdata/tiny.txt", []string{""}},
+		{"testdata/tiny.txt.gz", []string{""}},
+		{"testdata/tiny.zip", []string{"tiny/tiny.txt"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			members, err := Members(tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(members, tc.members) {
+				t.Fatalf("got members %q, want %q", members, tc.members)
+			}
+			for _, member := range members {
+				rc, err := Open(tc.name, member)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(rc)
+				if cerr := rc.Close(); err == nil {
+					err = cerr
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != string(want) {
+					t.Errorf("member %q: got %q, want %q", member, got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := Open("testdata/tiny.zip", "tiny/missing.txt"); err == nil {
+		t.Errorf("expected an error opening a missing member")
+	}
+}
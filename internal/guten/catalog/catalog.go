@@ -0,0 +1,146 @@
+// Package catalog reads the Project Gutenberg RDF catalog, as distributed
+// offline in rdf-files.tar.bz2, for authoritative e-book metadata.
+package catalog
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/jcorbin/markov/internal/model"
+)
+
+// Entry is the catalog metadata of one e-book.
+type Entry struct {
+	Number   int
+	Title    string
+	Authors  []Agent
+	Subjects []string // LCSH subject headings
+	LoCC     []string // Library of Congress classes
+	Language []string // RFC 4646 codes, e.g. "en"
+	Rights   string
+}
+
+// Agent is an author, or other creator, of an e-book; years are 0 if
+// unknown, and negative if BCE.
+type Agent struct {
+	Name  string // e.g. "Austen, Jane"
+	Birth int
+	Death int
+}
+
+// DisplayName returns the name as in e-book headers: "Austen, Jane" becomes
+// "Jane Austen".
+func (a Agent) DisplayName() string {
+	parts := strings.SplitN(a.Name, ", ", 2)
+	if len(parts) < 2 {
+		return a.Name
+	}
+	return parts[1] + " " + parts[0]
+}
+
+func (a Agent) String() string {
+	if a.Birth == 0 && a.Death == 0 {
+		return a.Name
+	}
+	years := func(y int) string {
+		if y == 0 {
+			return "?"
+		} else if y < 0 {
+			return fmt.Sprintf("%d BCE", -y)
+		}
+		return strconv.Itoa(y)
+	}
+	return fmt.Sprintf("%s, %s-%s", a.Name, years(a.Birth), years(a.Death))
+}
+
+// Info returns the entry as e-book header meta info, for DocInfo.Info; only
+// known fields are set.
+func (e Entry) Info() map[string]string {
+	info := make(map[string]string, 7)
+	set := func(key string, vals []string, sep string) {
+		if len(vals) > 0 {
+			info[key] = strings.Join(vals, sep)
+		}
+	}
+	if e.Title != "" {
+		info["Title"] = e.Title
+	}
+	names := make([]string, len(e.Authors))
+	agents := make([]string, len(e.Authors))
+	for i, a := range e.Authors {
+		names[i] = a.DisplayName()
+		agents[i] = a.String()
+	}
+	set("Author", names, " and ")
+	set("Authors", agents, "\n")
+	set("Subject", e.Subjects, "\n")
+	set("LoCC", e.LoCC, "\n")
+	langs := make([]string, len(e.Language))
+	for i, code := range e.Language {
+		if name, ok := model.LanguageNames[code]; ok {
+			langs[i] = name
+		} else {
+			langs[i] = code
+		}
+	}
+	set("Language", langs, " and ")
+	if e.Rights != "" {
+		info["Rights"] = e.Rights
+	}
+	return info
+}
+
+// Catalog maps e-book numbers to entries.
+type Catalog map[int]Entry
+
+// Load reads a catalog from a local rdf-files.tar.bz2 file.
+func Load(name string) (cat Catalog, rerr error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := f.Close(); rerr == nil {
+			rerr = cerr
+		}
+	}()
+	cat = make(Catalog)
+	err = Read(bzip2.NewReader(f), func(e Entry) error {
+		cat[e.Number] = e
+		return nil
+	})
+	return cat, err
+}
+
+// Read reads a tar stream of RDF files, calling f with each entry; any error
+// returned by f halts reading, and is returned.
+func Read(r io.Reader, f func(Entry) error) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Ext(hdr.Name) != ".rdf" {
+			continue
+		}
+		e, err := ParseRDF(tr)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q: %v", hdr.Name, err)
+		}
+		if e.Number == 0 {
+			continue
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+}
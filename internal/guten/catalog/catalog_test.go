@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+var federalist = Entry{
+	Number: 1404,
+	Title:  "The Federalist Papers",
+	Authors: []Agent{
+		{"Hamilton, Alexander", 1757, 1804},
+		{"Jay, John", 1745, 1829},
+		{"Madison, James", 1751, 1836},
+	},
+	Subjects: []string{
+		"Constitutional history -- United States -- Sources",
+		"Constitutional law -- United States",
+	},
+	LoCC:     []string{"JK"},
+	Language: []string{"en"},
+	Rights:   "Public domain in the USA.",
+}
+
+func TestParseRDF(t *testing.T) {
+	f, err := os.Open("testdata/pg1404.rdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	e, err := ParseRDF(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e, federalist) {
+		t.Errorf("got %+v, want %+v", e, federalist)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cat, err := Load("testdata/rdf-files.tar.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the README, and the rdf file of no e-book, are skipped
+	want := Catalog{1404: federalist}
+	if !reflect.DeepEqual(cat, want) {
+		t.Errorf("got %+v, want %+v", cat, want)
+	}
+}
+
+func TestEntryInfo(t *testing.T) {
+	want := map[string]string{
+		"Title":    "The Federalist Papers",
+		"Author":   "Alexander Hamilton and John Jay and James Madison",
+		"Authors":  "Hamilton, Alexander, 1757-1804\nJay, John, 1745-1829\nMadison, James, 1751-1836",
+		"Subject":  "Constitutional history -- United States -- Sources\nConstitutional law -- United States",
+		"LoCC":     "JK",
+		"Language": "English",
+		"Rights":   "Public domain in the USA.",
+	}
+	if got := federalist.Info(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// unknown language codes are kept as is
+	e := Entry{Language: []string{"en", "ang"}}
+	if got, want := e.Info()["Language"], "English and ang"; got != want {
+		t.Errorf("got language %q, want %q", got, want)
+	}
+}
+
+func TestAgentString(t *testing.T) {
+	for _, tc := range []struct {
+		agent Agent
+		want  string
+	}{
+		{Agent{"Austen, Jane", 1775, 1817}, "Austen, Jane, 1775-1817"},
+		{Agent{"Homer", -750, 0}, "Homer, 750 BCE-?"},
+		{Agent{"Anonymous", 0, 0}, "Anonymous"},
+	} {
+		if got := tc.agent.String(); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const nsDCTerms = "http://purl.org/dc/terms/"
+
+// NOTE: encoding/xml only matches namespaces on the last element of an a>b
+// path, so each nested element gets its own struct.
+
+type rdfDoc struct {
+	EBook rdfEBook `xml:"http://www.gutenberg.org/2009/pgterms/ ebook"`
+}
+
+type rdfEBook struct {
+	About    string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title    []string `xml:"http://purl.org/dc/terms/ title"`
+	Creators []struct {
+		Agent rdfAgent `xml:"http://www.gutenberg.org/2009/pgterms/ agent"`
+	} `xml:"http://purl.org/dc/terms/ creator"`
+	Subjects []struct {
+		Desc rdfValue `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Description"`
+	} `xml:"http://purl.org/dc/terms/ subject"`
+	Language []struct {
+		Desc rdfValue `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Description"`
+	} `xml:"http://purl.org/dc/terms/ language"`
+	Rights string `xml:"http://purl.org/dc/terms/ rights"`
+}
+
+type rdfAgent struct {
+	Name  string `xml:"http://www.gutenberg.org/2009/pgterms/ name"`
+	Birth string `xml:"http://www.gutenberg.org/2009/pgterms/ birthdate"`
+	Death string `xml:"http://www.gutenberg.org/2009/pgterms/ deathdate"`
+}
+
+type rdfValue struct {
+	MemberOf struct {
+		Resource string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# resource,attr"`
+	} `xml:"http://purl.org/dc/dcam/ memberOf"`
+	Value string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# value"`
+}
+
+// ParseRDF parses one e-book's RDF file, e.g. cache/epub/1342/pg1342.rdf
+// from the catalog; Number is 0 if the file describes no e-book.
+func ParseRDF(r io.Reader) (Entry, error) {
+	var doc rdfDoc
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return Entry{}, err
+	}
+	eb := doc.EBook
+
+	var e Entry
+	e.Number, _ = strconv.Atoi(path.Base(eb.About)) // e.g. "ebooks/1342"
+	if len(eb.Title) > 0 {
+		e.Title = strings.Replace(strings.TrimSpace(eb.Title[0]), "\r\n", "\n", -1)
+	}
+	for _, c := range eb.Creators {
+		a := c.Agent
+		birth, _ := strconv.Atoi(strings.TrimSpace(a.Birth))
+		death, _ := strconv.Atoi(strings.TrimSpace(a.Death))
+		e.Authors = append(e.Authors, Agent{
+			Name:  strings.TrimSpace(a.Name),
+			Birth: birth,
+			Death: death,
+		})
+	}
+	for _, s := range eb.Subjects {
+		val := strings.TrimSpace(s.Desc.Value)
+		switch s.Desc.MemberOf.Resource {
+		case nsDCTerms + "LCSH":
+			e.Subjects = append(e.Subjects, val)
+		case nsDCTerms + "LCC":
+			e.LoCC = append(e.LoCC, val)
+		}
+	}
+	for _, lang := range eb.Language {
+		e.Language = append(e.Language, strings.TrimSpace(lang.Desc.Value))
+	}
+	e.Rights = strings.TrimSpace(eb.Rights)
+	return e, nil
+}
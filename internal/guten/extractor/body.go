@@ -17,16 +17,38 @@ type BodyResultor interface {
 	Close() error
 }
 
+// SectionResultor may be optionally implemented by a BodyResultor to receive
+// section header events; ordinal counts from 1 for the first header after the
+// body has begun.
+type SectionResultor interface {
+	OnSection(ordinal int, header string) error
+}
+
 type bodyExtractor struct {
 	title   string
 	blanks  int
 	began   bool
+	section int
 	buf     [][]byte
 	procBuf bytes.Buffer
 	res     BodyResultor
+	markup  Markup
+
+	// front and back matter region, if in one
+	matter       Matter
+	matterHeader string
+	matterParas  [][]string
+	sawFront     bool
 }
 
 func (be *bodyExtractor) close() error {
+	// the final paragraph is only ended by the end of the body
+	if err := be.flushSection(); err != nil {
+		return err
+	}
+	if err := be.endMatter(); err != nil {
+		return err
+	}
 	if !be.began {
 		return errEmptyBody
 	}
@@ -50,7 +72,8 @@ func (be *bodyExtractor) data(buf []byte) error {
 	}
 	be.blanks = 0
 
-	be.buf = append(be.buf, buf)
+	// the scanner may reuse buf
+	be.buf = append(be.buf, append([]byte(nil), buf...))
 	return nil
 }
 
@@ -59,26 +82,113 @@ func (be *bodyExtractor) flushSection() error {
 	case 0:
 		return nil
 	case 1:
+		if _, ok := classifyPara(be.buf); ok {
+			// e.g. a one line footnote
+			break
+		}
 		if header := strings.TrimSpace(string(be.buf[0])); len(header) > 0 {
-			// TODO: expose
-			// fmt.Printf("SECTION: %q\n", header)
-
-			if strings.ToLower(header) == be.title {
-				// fmt.Printf("BEGIN\n")
-				be.began = true
-			}
-
 			be.buf = be.buf[:0]
-			return nil
+			return be.onHeader(header, true)
 		}
 	}
 	return be.flushPara()
 }
 
+// onHeader handles a section header: it may start a front or back matter
+// region, which lasts until the next other header, or begin the body; section
+// is true if it was followed by at least two blank lines. Front matter isn't
+// expected once the body has begun.
+func (be *bodyExtractor) onHeader(header string, section bool) error {
+	if err := be.endMatter(); err != nil {
+		return err
+	}
+	if m, ok := classifyHeader(header, section); ok && (!be.began || !m.Front()) {
+		be.matter, be.matterHeader = m, header
+		if !m.Back() {
+			be.sawFront = true
+		}
+		return nil
+	}
+	if !be.began {
+		switch {
+		case strings.ToLower(header) == be.title:
+			be.began = true
+			return nil
+		case chapterPat.MatchString(header), be.sawFront:
+			be.began = true
+		default:
+			return nil
+		}
+	}
+	return be.emitSection(header)
+}
+
+// endMatter ends any front or back matter region, reporting it.
+func (be *bodyExtractor) endMatter() error {
+	if be.matter == 0 {
+		return nil
+	}
+	m, header, paras := be.matter, be.matterHeader, be.matterParas
+	be.matter, be.matterHeader, be.matterParas = 0, "", nil
+	return be.emitMatter(m, header, paras)
+}
+
+func (be *bodyExtractor) emitMatter(m Matter, header string, paras [][]string) error {
+	if m == Footnotes && be.markup.Footnotes {
+		// footnote blocks are also reported footnote by footnote
+		for _, para := range paras {
+			if err := be.emitFootnotes(strings.Join(para, "\n")); err != nil {
+				return err
+			}
+		}
+	}
+	if mr, ok := be.res.(MatterResultor); ok {
+		return mr.OnMatter(m, header, paras)
+	}
+	return nil
+}
+
+// paraLines returns the buffered paragraph as strings.
+func (be *bodyExtractor) paraLines() []string {
+	lines := make([]string, len(be.buf))
+	for i, bline := range be.buf {
+		lines[i] = string(bline)
+	}
+	return lines
+}
+
+func (be *bodyExtractor) emitSection(header string) error {
+	be.section++
+	if sr, ok := be.res.(SectionResultor); ok {
+		return sr.OnSection(be.section, header)
+	}
+	return nil
+}
+
 func (be *bodyExtractor) flushPara() error {
 	if len(be.buf) == 0 {
 		return nil
 	}
+	if len(be.buf) == 1 {
+		// e.g. "CONTENTS" followed by only one blank line, but not a line
+		// of prose that just starts with a keyword
+		if header := strings.TrimSpace(string(be.buf[0])); header != "" {
+			if _, ok := classifyHeader(header, false); ok {
+				be.buf = be.buf[:0]
+				return be.onHeader(header, false)
+			}
+		}
+	}
+	if be.matter != 0 {
+		be.matterParas = append(be.matterParas, be.paraLines())
+		be.buf = be.buf[:0]
+		return nil
+	}
+	if m, ok := classifyPara(be.buf); ok {
+		paras := [][]string{be.paraLines()}
+		be.buf = be.buf[:0]
+		return be.emitMatter(m, "", paras)
+	}
 	if !be.began {
 		return be.mayBegin()
 	}
@@ -96,40 +206,34 @@ func (be *bodyExtractor) mayBegin() error {
 	return nil
 }
 
+// isBegin returns true if the buffered paragraph looks like the first of
+// the body: prose of at least 3 unindented lines, averaging at least
+// minProseLine bytes.
 func (be *bodyExtractor) isBegin() bool {
-	// // only begin with a paragraph that's at least 2 lines
-	// if len(be.buf) < 2 {
-	// 	return false
-	// }
-
-	// // heuristic: if more than the initial line are indented, than take it as a
-	// // sign of formatted pre-amble (and skip / don't begin yet)
-	// for _, bline := range be.buf[1:] {
-	// 	line := string(bline)
-	// 	if strings.TrimLeft(line, " \t") != line {
-	// 		return false
-	// 	}
-	// }
-	// fmt.Printf("BEGIN: %q\n", be.buf)
-	// return true
-
-	return false
+	if len(be.buf) < 3 {
+		return false
+	}
+	n := 0
+	for _, bline := range be.buf {
+		if isFormatted(bline) {
+			return false
+		}
+		n += len(bline)
+	}
+	return n >= minProseLine*len(be.buf)
 }
 
+// minProseLine is the minimum average line length of a paragraph that may
+// begin the body.
+const minProseLine = 50
+
 func (be *bodyExtractor) emitParagraph() error {
 	defer func() {
 		be.buf = be.buf[:0]
 	}()
 
-	// skip if the all lines are "formatted"
-	formatted := true
-	for _, bline := range be.buf {
-		if !isFormatted(bline) {
-			formatted = false
-			break
-		}
-	}
-	if formatted {
+	kind := classify(be.buf)
+	if kind == Formatted {
 		// fmt.Printf("SKIP %q\n", be.buf)
 		return nil
 	}
@@ -149,6 +253,40 @@ func (be *bodyExtractor) emitParagraph() error {
 		be.procBuf.Write(p)
 		be.procBuf.WriteRune('\n')
 	}
+	text, err := be.markup.normalize(be.procBuf.String(), be.onFootnote)
+	if err != nil {
+		return err
+	}
+
+	vr, ok := be.res.(VerseResultor)
+	if !ok || kind != Verse {
+		if err := be.emitTokens(text); err != nil {
+			return err
+		}
+		return be.res.EndParagraph()
+	}
+
+	if err := vr.BeginVerse(); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			// e.g. a line of removed markup
+			continue
+		}
+		if err := be.emitTokens(line); err != nil {
+			return err
+		}
+		if err := vr.EndLine(); err != nil {
+			return err
+		}
+	}
+	return be.res.EndParagraph()
+}
+
+func (be *bodyExtractor) emitTokens(text string) error {
+	be.procBuf.Reset()
+	be.procBuf.WriteString(text)
 	sc := bufio.NewScanner(&be.procBuf)
 	sc.Split(ScanTokens)
 	for sc.Scan() {
@@ -156,9 +294,5 @@ func (be *bodyExtractor) emitParagraph() error {
 			return err
 		}
 	}
-	if err := sc.Err(); err != nil {
-		return err
-	}
-
-	return be.res.EndParagraph()
+	return sc.Err()
 }
@@ -3,6 +3,7 @@ package extractor
 import (
 	"bytes"
 	"errors"
+	"regexp"
 	"strings"
 
 	"github.com/jcorbin/markov/internal/guten/scanner"
@@ -29,11 +30,16 @@ type Resultor interface {
 
 // New creates a new extractor that will call the given Resultor; it implements
 // scanner.Resultor, and expects to be called by one run of a scanner.Scanner.
-func New(res Resultor) *Extractor {
-	return &Extractor{
-		be:  bodyExtractor{res: res},
+// Markup is normalized by DefaultMarkup unless WithMarkup is given.
+func New(res Resultor, opts ...Option) *Extractor {
+	e := &Extractor{
+		be:  bodyExtractor{res: res, markup: DefaultMarkup},
 		res: res,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 var errPrematureClose = errors.New("premature Extractor close")
@@ -55,9 +61,25 @@ func (e *Extractor) Meta(key, val string) error {
 		e.info = make(map[string]string, 1)
 	}
 	e.info[key] = val
+	e.findNumber(val)
 	return nil
 }
 
+// ebookNumberPat matches an e-book number in the header, e.g. "[EBook #1000]"
+// in the release date, or "[Etext #1000]" on a line of its own.
+var ebookNumberPat = regexp.MustCompile(`(?i)\be-?(?:book|text)\s*#\s*(\d+)`)
+
+// findNumber stores the first e-book number found in the header as the
+// EBOOK_NUMBER meta info.
+func (e *Extractor) findNumber(s string) {
+	if _, def := e.info["EBOOK_NUMBER"]; def {
+		return
+	}
+	if m := ebookNumberPat.FindStringSubmatch(s); len(m) > 0 {
+		e.info["EBOOK_NUMBER"] = m[1]
+	}
+}
+
 // Close halts extraction, returning an error if we haven't even gotten started
 // (still scanning meta data).
 func (e *Extractor) Close() error {
@@ -106,8 +128,11 @@ func (e *Extractor) Boundary(end bool, name string) error {
 			}
 		}
 	case stateBody:
-		// TODO: !end :shrug:
-		e.state = stateDone
+		// a repeated start, e.g. a start mark after the legacy small print
+		// license, is ignored
+		if end {
+			e.state = stateDone
+		}
 	case stateDone:
 		// TODO :shrug:
 	}
@@ -115,12 +140,19 @@ func (e *Extractor) Boundary(end bool, name string) error {
 }
 
 // Data processes a buffer of line data: passing it on to the body extractor if
-// in body state.
+// in body state, or searching it for the e-book number before then.
 func (e *Extractor) Data(buf []byte) error {
-	if e.state != stateBody {
-		return nil // TODO :shrug:
+	switch e.state {
+	case statePre:
+		if e.info == nil {
+			e.info = make(map[string]string, 1)
+		}
+		e.findNumber(string(buf))
+		return nil
+	case stateBody:
+		return e.be.data(buf)
 	}
-	return e.be.data(buf)
+	return nil // TODO :shrug:
 }
 
 var _ scanner.Resultor = &Extractor{}
@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Markup configures which inline markup artifacts are normalized out of body
+// paragraphs before tokenizing them.
+type Markup struct {
+	// Illustrations removes "[Illustration: caption]" markers.
+	Illustrations bool
+
+	// Footnotes removes "[Footnote 3: text]" bodies, passing them to any
+	// FootnoteResultor, and "[3]" references.
+	Footnotes bool
+
+	// Emphasis unwraps "_italic_" and "=bold=" text.
+	Emphasis bool
+
+	// PageNumbers removes "{page 12}", "{12}" and "[Pg 12]" markers.
+	PageNumbers bool
+
+	// EditorialNotes removes any other bracketed notes, e.g. "[sic]".
+	EditorialNotes bool
+}
+
+// DefaultMarkup normalizes all known markup artifacts.
+var DefaultMarkup = Markup{
+	Illustrations:  true,
+	Footnotes:      true,
+	Emphasis:       true,
+	PageNumbers:    true,
+	EditorialNotes: true,
+}
+
+// FootnoteResultor may be optionally implemented by a BodyResultor to
+// receive the bodies of footnotes removed from the text; label is e.g. "3",
+// or "" if the footnote isn't labeled.
+type FootnoteResultor interface {
+	OnFootnote(label, text string) error
+}
+
+var (
+	footnoteBodyPat       = regexp.MustCompile(`(?is)\[Footnote\s*([^:\]]*?)\s*:\s*(.*?)\]`)
+	footnoteLeadPat       = regexp.MustCompile(`(?s)^\s*\[(\d+|[A-Za-z*†‡§])\]\s*(.*?)\s*$`)
+	footnoteRefPat        = regexp.MustCompile(`\[(?:\d+|[A-Za-z*†‡§])\]`)
+	illustrationMarkupPat = regexp.MustCompile(`(?is)\[Illustration\b[^\]]*\]`)
+	pageNumberPat         = regexp.MustCompile(`(?i)\{(?:page\s*|pg\.?\s*|p\.\s*)?[ivxlcdm\d]+\}|\[(?:page|pg\.?|p\.)\s*[ivxlcdm\d]+\]`)
+	underscorePat         = regexp.MustCompile(`(?s)_([^_]+)_`)
+	boldPat               = regexp.MustCompile(`(?s)=([^=\s][^=]*)=`)
+	bracketNotePat        = regexp.MustCompile(`\[[^\[\]]*\]`)
+)
+
+// normalize normalizes markup out of paragraph text, calling footnote with
+// the label and text of each removed footnote body, if not nil.
+func (mu Markup) normalize(text string, footnote func(label, text string) error) (string, error) {
+	if mu.Footnotes {
+		var err error
+		text = footnoteBodyPat.ReplaceAllStringFunc(text, func(s string) string {
+			if m := footnoteBodyPat.FindStringSubmatch(s); err == nil && footnote != nil {
+				err = footnote(m[1], strings.Join(strings.Fields(m[2]), " "))
+			}
+			return " "
+		})
+		if err != nil {
+			return "", err
+		}
+		text = footnoteRefPat.ReplaceAllString(text, "")
+	}
+	if mu.Illustrations {
+		text = illustrationMarkupPat.ReplaceAllString(text, " ")
+	}
+	if mu.PageNumbers {
+		text = pageNumberPat.ReplaceAllString(text, "")
+	}
+	if mu.Emphasis {
+		text = underscorePat.ReplaceAllString(text, "$1")
+		text = boldPat.ReplaceAllString(text, "$1")
+	}
+	if mu.EditorialNotes {
+		text = bracketNotePat.ReplaceAllString(text, " ")
+	}
+	return text, nil
+}
+
+// emitFootnotes reports the footnotes in a paragraph of a footnote block,
+// e.g. "[Footnote 3: text]", or "[3] text".
+func (be *bodyExtractor) emitFootnotes(para string) error {
+	if m := footnoteLeadPat.FindStringSubmatch(para); m != nil && !footnoteBodyPat.MatchString(para) {
+		return be.onFootnote(m[1], strings.Join(strings.Fields(m[2]), " "))
+	}
+	_, err := be.markup.normalize(para, be.onFootnote)
+	return err
+}
+
+func (be *bodyExtractor) onFootnote(label, text string) error {
+	if fr, ok := be.res.(FootnoteResultor); ok {
+		return fr.OnFootnote(label, text)
+	}
+	return nil
+}
+
+// Option customizes an Extractor constructed by New.
+type Option func(*Extractor)
+
+// WithMarkup sets which markup artifacts are normalized, rather than
+// DefaultMarkup.
+func WithMarkup(mu Markup) Option {
+	return func(e *Extractor) { e.be.markup = mu }
+}
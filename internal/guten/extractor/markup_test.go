@@ -0,0 +1,110 @@
+package extractor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkupNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+
+		// enabled returns the toggle for this case's kind of artifact
+		enabled func(Markup) bool
+
+		// want is the text once the artifact is normalized out, with its
+		// footnotes; bracketed artifacts are also removed by EditorialNotes,
+		// but without reporting any footnotes
+		want      string
+		footnotes []string
+		bracketed bool
+	}{
+		{
+			name:      "illustration",
+			text:      "The inn [Illustration: The Inn\nat Night] stood.",
+			enabled:   func(mu Markup) bool { return mu.Illustrations },
+			want:      "The inn stood.",
+			bracketed: true,
+		},
+		{
+			name:      "footnote",
+			text:      "The walker[1] reached the inn.[Footnote 1: She was\ntired.]",
+			enabled:   func(mu Markup) bool { return mu.Footnotes },
+			want:      "The walker reached the inn.",
+			footnotes: []string{"1: She was tired."},
+			bracketed: true,
+		},
+		{
+			name:    "emphasis",
+			text:    "It was _very_ late, and =bold= too.",
+			enabled: func(mu Markup) bool { return mu.Emphasis },
+			want:    "It was very late, and bold too.",
+		},
+		{
+			name:    "page number",
+			text:    "It was late{page 12} at night.",
+			enabled: func(mu Markup) bool { return mu.PageNumbers },
+			want:    "It was late at night.",
+		},
+		{
+			name:    "editorial note",
+			text:    "The keeper [sic] said nothing.",
+			enabled: func(mu Markup) bool { return mu.EditorialNotes },
+			want:    "The keeper said nothing.",
+		},
+	} {
+		for bits := 0; bits < 1<<5; bits++ {
+			mu := Markup{
+				Illustrations:  bits&1 != 0,
+				Footnotes:      bits&2 != 0,
+				Emphasis:       bits&4 != 0,
+				PageNumbers:    bits&8 != 0,
+				EditorialNotes: bits&16 != 0,
+			}
+			t.Run(fmt.Sprintf("%s %+v", tc.name, mu), func(t *testing.T) {
+				want, footnotes := tc.text, []string(nil)
+				switch {
+				case tc.enabled(mu):
+					want, footnotes = tc.want, tc.footnotes
+				case tc.bracketed && mu.EditorialNotes:
+					want = tc.want
+				}
+
+				var rec footnoteRecorder
+				e := New(&rec, WithMarkup(mu))
+				got, err := e.be.markup.normalize(tc.text, e.be.onFootnote)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// normalization leaves space where artifacts were
+				if got, want := strings.Join(strings.Fields(got), " "), strings.Join(strings.Fields(want), " "); got != want {
+					t.Errorf("got %q, want %q", got, want)
+				}
+				if !reflect.DeepEqual(rec.footnotes, footnotes) {
+					t.Errorf("got footnotes %q, want %q", rec.footnotes, footnotes)
+				}
+			})
+		}
+	}
+}
+
+// footnoteRecorder is a Resultor that records footnotes as "label: text".
+type footnoteRecorder struct {
+	footnotes []string
+}
+
+func (fr *footnoteRecorder) SetTitle(string) error           { return nil }
+func (fr *footnoteRecorder) SetInfo(map[string]string) error { return nil }
+func (fr *footnoteRecorder) OnToken([]byte) error            { return nil }
+func (fr *footnoteRecorder) EndParagraph() error             { return nil }
+func (fr *footnoteRecorder) Close() error                    { return nil }
+
+func (fr *footnoteRecorder) OnFootnote(label, text string) error {
+	fr.footnotes = append(fr.footnotes, label+": "+text)
+	return nil
+}
+
+var _ FootnoteResultor = &footnoteRecorder{}
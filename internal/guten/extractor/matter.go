@@ -0,0 +1,196 @@
+package extractor
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Matter classifies a region of front or back matter, which is reported to
+// any MatterResultor rather than extracted as body text.
+type Matter uint8
+
+const (
+	// Contents is a table of contents.
+	Contents Matter = iota + 1
+
+	// Illustrations is a list of illustrations, or an illustration caption.
+	Illustrations
+
+	// TranscriberNote is a transcriber's or editor's note.
+	TranscriberNote
+
+	// Preface is a preface, foreword, or introduction.
+	Preface
+
+	// Index is a back matter index.
+	Index
+
+	// Footnotes is a block of footnotes.
+	Footnotes
+
+	// Advertisements are publisher's advertisements.
+	Advertisements
+)
+
+var matterNames = []string{"", "contents", "illustrations", "transcriberNote", "preface", "index", "footnotes", "advertisements"}
+
+func (m Matter) String() string {
+	if int(m) < len(matterNames) {
+		return matterNames[m]
+	}
+	return "unknown"
+}
+
+// Front returns true for matter that only precedes the body; transcriber's
+// notes may be either.
+func (m Matter) Front() bool {
+	switch m {
+	case Contents, Illustrations, Preface:
+		return true
+	}
+	return false
+}
+
+// Back returns true for back matter.
+func (m Matter) Back() bool {
+	switch m {
+	case Index, Footnotes, Advertisements:
+		return true
+	}
+	return false
+}
+
+// MatterResultor may be optionally implemented by a BodyResultor to receive
+// front and back matter regions; header is the section header that started
+// the region, if any, and paras are its paragraphs of lines.
+type MatterResultor interface {
+	OnMatter(m Matter, header string, paras [][]string) error
+}
+
+// matterHeaders classify section headers, by keyword once upper cased and
+// stripped of trailing punctuation.
+var matterHeaders = []struct {
+	keyword string
+	matter  Matter
+}{
+	{"CONTENTS", Contents},
+	{"TABLE OF CONTENTS", Contents},
+	{"LIST OF ILLUSTRATIONS", Illustrations},
+	{"ILLUSTRATIONS", Illustrations},
+	{"LIST OF PLATES", Illustrations},
+	{"TRANSCRIBER'S NOTE", TranscriberNote},
+	{"TRANSCRIBERS NOTE", TranscriberNote},
+	{"TRANSCRIBER’S NOTE", TranscriberNote},
+	{"EDITOR'S NOTE", TranscriberNote},
+	{"PREFACE", Preface},
+	{"FOREWORD", Preface},
+	{"PREFATORY NOTE", Preface},
+	{"INTRODUCTION", Preface},
+	{"INDEX", Index},
+	{"GENERAL INDEX", Index},
+	{"FOOTNOTES", Footnotes},
+	{"NOTES", Footnotes},
+	{"ENDNOTES", Footnotes},
+	{"ADVERTISEMENTS", Advertisements},
+	{"ADVERTISEMENT", Advertisements},
+}
+
+// maxHeaderLen is the maximum length, in runes, of a header that only starts
+// with a keyword, e.g. "PREFACE TO THE SECOND EDITION".
+const maxHeaderLen = 60
+
+// classifyHeader classifies a section header: the whole line must be a
+// keyword, unless the line is set apart as a section (followed by at least
+// two blank lines), when it may be a heading that starts with one.
+func classifyHeader(header string, section bool) (Matter, bool) {
+	header = strings.TrimRight(strings.TrimSpace(header), ".: ")
+	h := strings.ToUpper(header)
+	for _, mh := range matterHeaders {
+		if h == mh.keyword {
+			return mh.matter, true
+		}
+	}
+	if !section || !isHeading(header) {
+		return 0, false
+	}
+	for _, mh := range matterHeaders {
+		if strings.HasPrefix(h, mh.keyword+" ") {
+			return mh.matter, true
+		}
+	}
+	return 0, false
+}
+
+// isHeading returns true for a short line in all caps, or in title case,
+// where only the short words after the first may be lower case.
+func isHeading(line string) bool {
+	if utf8.RuneCountInString(line) > maxHeaderLen {
+		return false
+	}
+	for i, word := range strings.Fields(line) {
+		word = strings.TrimLeftFunc(word, unicode.IsPunct)
+		r, _ := utf8.DecodeRuneInString(word)
+		if unicode.IsLower(r) && (i == 0 || utf8.RuneCountInString(word) > 3) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	chapterPat = regexp.MustCompile(`(?i)^(?:chapter|book|part|stave|canto)\s+(?:[ivxlcdm]+|\d+|one|the first)\b`)
+
+	// e.g. "CHAPTER I. THE BEGINNING ........ 1", "Chapter 1", "The Beginning   17"
+	tocLinePat = regexp.MustCompile(`(?i)^\s*(?:(?:chapter|book|part)\s+[ivxlcdm\d]+\b.*|.*(?:(?:\.{3,}\s*|\s{2,})[ivxlcdm\d]+|\s\d+)\.?)\s*$`)
+
+	// e.g. "Adams, John, 12, 45-47"
+	indexLinePat = regexp.MustCompile(`,\s*\d+(?:\s*[-–]\s*\d+)?(?:,\s*\d+(?:\s*[-–]\s*\d+)?)*\.?\s*$`)
+
+	// e.g. "[Transcriber's Note: ...", or "TRANSCRIBER'S NOTES" alone
+	transcriberPat  = regexp.MustCompile(`(?i)^\s*\[?\s*(?:transcriber|editor)(?:'|’)?s?\s+notes?\s*(?:[:.\]]|$)`)
+	footnotePat     = regexp.MustCompile(`(?i)^\s*\[(?:footnote\s*)?(?:\d+|[a-z*†])[\]:]`)
+	illustrationPat = regexp.MustCompile(`(?i)^\s*\[illustration\b`)
+)
+
+// classifyPara classifies a paragraph of front or back matter by its lines.
+func classifyPara(lines [][]byte) (Matter, bool) {
+	if len(lines) == 0 {
+		return 0, false
+	}
+	switch first := lines[0]; {
+	case transcriberPat.Match(first):
+		return TranscriberNote, true
+	case footnotePat.Match(first):
+		return Footnotes, true
+	case illustrationPat.Match(first):
+		return Illustrations, true
+	}
+	if m, ok := classifyHeader(string(lines[0]), false); ok && len(lines) > 1 {
+		// e.g. "CONTENTS" followed by its entries; the whole line must be
+		// a keyword, not the start of running prose
+		return m, true
+	}
+	if len(lines) < 3 {
+		return 0, false
+	}
+	if mostly(lines, indexLinePat) {
+		return Index, true
+	}
+	if mostly(lines, tocLinePat) {
+		return Contents, true
+	}
+	return 0, false
+}
+
+// mostly returns true if at least two thirds of lines match pat.
+func mostly(lines [][]byte, pat *regexp.Regexp) bool {
+	n := 0
+	for _, line := range lines {
+		if pat.Match(line) {
+			n++
+		}
+	}
+	return 3*n >= 2*len(lines)
+}
@@ -0,0 +1,224 @@
+package extractor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// paraLines splits a test paragraph, dropping a leading newline.
+func paraLines(para string) [][]byte {
+	var lines [][]byte
+	for _, line := range strings.Split(strings.TrimPrefix(para, "\n"), "\n") {
+		lines = append(lines, []byte(line))
+	}
+	return lines
+}
+
+func TestClassifyHeader(t *testing.T) {
+	for _, tc := range []struct {
+		header  string
+		section bool
+		want    Matter
+	}{
+		{"CONTENTS", false, Contents},
+		{"Contents.", false, Contents},
+		{"TABLE OF CONTENTS", false, Contents},
+		{"INDEX", true, Index},
+		{"Notes:", false, Footnotes},
+		{"TRANSCRIBER'S NOTE", false, TranscriberNote},
+		{"PREFACE TO THE SECOND EDITION", true, Preface},
+		{"Preface to the Second Edition", true, Preface},
+		{"LIST OF ILLUSTRATIONS IN COLOUR", true, Illustrations},
+
+		// headings must be set apart as sections
+		{"PREFACE TO THE SECOND EDITION", false, 0},
+
+		// lookalikes
+		{"Index finger raised, he spoke.", true, 0},
+		{"Index finger raised, he spoke.", false, 0},
+		{"Contents of the letter were unknown.", true, 0},
+		{"Notes of music drifted in.", true, 0},
+		{"INTRODUCTION TO A LONG AND WINDING TALE OF THE SEA AND ITS SHORES", true, 0},
+		{"CHAPTER I", true, 0},
+	} {
+		got, ok := classifyHeader(tc.header, tc.section)
+		if ok != (tc.want != 0) || got != tc.want {
+			t.Errorf("classifyHeader(%q, %v) = %v, %v; want %v", tc.header, tc.section, got, ok, tc.want)
+		}
+	}
+}
+
+func TestClassifyPara(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		para string
+		want Matter
+	}{
+		{"contents", `
+CONTENTS
+CHAPTER I. The Road .... 1
+CHAPTER II. The Inn .... 9
+CHAPTER III. The Sea .... 17`, Contents},
+		{"contents entries", `
+The Road                     1
+The Inn                      9
+The Sea                     17`, Contents},
+		{"index", `
+Adams, John, 12, 45-47
+Baker Street, 3
+Cabbage, boiled, 1, 9`, Index},
+		{"transcriber's note", `
+[Transcriber's Note: Obvious typographical errors
+have been corrected.]`, TranscriberNote},
+		{"transcriber's note header", `
+TRANSCRIBER'S NOTES
+Spelling is as in the original.`, TranscriberNote},
+		{"footnote", `
+[1] She was tired.`, Footnotes},
+		{"illustration", `
+[Illustration: The Inn at Night]`, Illustrations},
+
+		// lookalikes
+		{"contents prose", `
+Contents of the letter were unknown to all of them, and none of the
+walkers would say who had written it, though each of them had read it
+twice over by the light of the fire.`, 0},
+		{"index prose", `
+Index finger raised, he spoke to the crowd that had gathered by the
+gate, and told them that the road to the sea was closed.`, 0},
+		{"editor's notes prose", `
+Editor's notes were scattered across the desk, and she gathered them
+up before anyone could read them.`, 0},
+		{"short prose", `
+Notes.`, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := classifyPara(paraLines(tc.para))
+			if ok != (tc.want != 0) || got != tc.want {
+				t.Errorf("got %v, %v; want %v", got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsBegin(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		para string
+		want bool
+	}{
+		{"prose", `
+It was a long road to the sea, and the walkers went along it slowly,
+talking of nothing in particular while the sun went down behind them,
+and the gulls called out over the water as the evening came on.`, true},
+		{"two lines", `
+It was a long road to the sea, and the walkers went along it slowly,
+talking of nothing in particular while the sun went down behind them.`, false},
+		{"contents", `
+CHAPTER I. The Road .... 1
+CHAPTER II. The Inn .... 9
+CHAPTER III. The Sea .... 17`, false},
+		{"title page", `
+A BRIGHT DAY
+BY JANE DOE
+LONDON: PRINTED FOR THE AUTHOR, 1851`, false},
+		{"indented", `
+    It was a long road to the sea, and the walkers went along it slowly,
+    talking of nothing in particular while the sun went down behind them,
+    and the gulls called out over the water as the evening came on.`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			be := bodyExtractor{buf: paraLines(tc.para)}
+			if got := be.isBegin(); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatterRegions(t *testing.T) {
+	doc := `
+CONTENTS
+
+CHAPTER I. The Road .... 1
+CHAPTER II. The Inn .... 9
+
+
+CHAPTER I
+
+
+It was a long road to the sea, and the walkers went along it slowly,
+talking of nothing in particular while the sun went down behind them.
+
+Index finger raised, he spoke.
+
+Contents of the letter were unknown to all of them, and none of the
+walkers would say who had written it.
+
+
+INDEX
+
+
+Inn, the, 9
+Road, the, 1, 3-5
+Sea, 1`
+
+	var rec matterRecorder
+	e := New(&rec)
+	if err := e.Boundary(false, "A Long Road"); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimPrefix(doc, "\n"), "\n") {
+		if err := e.Data([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantParas := []string{
+		"It was a long road to the sea , and the walkers went along it slowly , " +
+			"talking of nothing in particular while the sun went down behind them .",
+		"Index finger raised , he spoke .",
+		"Contents of the letter were unknown to all of them , and none of the " +
+			"walkers would say who had written it .",
+	}
+	if !reflect.DeepEqual(rec.paras, wantParas) {
+		t.Errorf("got paragraphs %q, want %q", rec.paras, wantParas)
+	}
+	if want := []string{"contents CONTENTS", "index INDEX"}; !reflect.DeepEqual(rec.matter, want) {
+		t.Errorf("got matter %q, want %q", rec.matter, want)
+	}
+}
+
+// matterRecorder is a Resultor that records body paragraphs as space
+// separated tokens, and matter regions as "kind header".
+type matterRecorder struct {
+	toks   []string
+	paras  []string
+	matter []string
+}
+
+func (mr *matterRecorder) SetTitle(string) error           { return nil }
+func (mr *matterRecorder) SetInfo(map[string]string) error { return nil }
+func (mr *matterRecorder) Close() error                    { return nil }
+
+func (mr *matterRecorder) OnToken(tok []byte) error {
+	mr.toks = append(mr.toks, string(tok))
+	return nil
+}
+
+func (mr *matterRecorder) EndParagraph() error {
+	mr.paras = append(mr.paras, strings.Join(mr.toks, " "))
+	mr.toks = mr.toks[:0]
+	return nil
+}
+
+func (mr *matterRecorder) OnMatter(m Matter, header string, paras [][]string) error {
+	mr.matter = append(mr.matter, m.String()+" "+header)
+	return nil
+}
+
+var _ MatterResultor = &matterRecorder{}
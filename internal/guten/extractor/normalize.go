@@ -0,0 +1,62 @@
+package extractor
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jcorbin/markov/internal/guten/scanner"
+)
+
+// Normalize normalizes a body token for language modeling: sentence
+// terminators are kept, other punctuation is dropped, and words are lower
+// cased. Returns false if the token should be dropped.
+func Normalize(tok []byte) (string, bool) {
+	// TODO: handle numeric tokens specially
+
+	if r, width := utf8.DecodeRune(tok); width == len(tok) {
+		switch r {
+
+		case '.', '!', '?':
+			return string(r), true
+
+		case ':': // TODO: could be a register/mode switch
+			return "", false
+
+		case ',': // TODO also ingest without comma
+			return "", false
+
+		case ';': // TODO also ingest as an end/start-of-chain
+			return "", false
+
+		default:
+			if unicode.IsPunct(r) {
+				return "", false
+			}
+
+		}
+	}
+
+	return strings.ToLower(string(tok)), true
+}
+
+// EachToken extracts the body of a Project Gutenberg e-book read from r,
+// calling f with each normalized token, and with "" at the end of each
+// paragraph; opts should match those the e-book was mined with.
+func EachToken(r io.Reader, f func(tok string) error, opts ...Option) error {
+	return scanner.New(r, New(tokenResultor(f), opts...)).Scan()
+}
+
+type tokenResultor func(tok string) error
+
+func (tr tokenResultor) SetTitle(string) error           { return nil }
+func (tr tokenResultor) SetInfo(map[string]string) error { return nil }
+func (tr tokenResultor) EndParagraph() error             { return tr("") }
+func (tr tokenResultor) Close() error                    { return nil }
+func (tr tokenResultor) OnToken(tok []byte) error {
+	if stok, ok := Normalize(tok); ok {
+		return tr(stok)
+	}
+	return nil
+}
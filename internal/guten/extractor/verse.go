@@ -0,0 +1,129 @@
+package extractor
+
+import (
+	"bytes"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind classifies a body paragraph.
+type Kind uint8
+
+const (
+	// Prose is running text, whose line breaks are insignificant.
+	Prose Kind = iota
+
+	// Verse is poetry, whose line breaks are kept.
+	Verse
+
+	// Formatted is a table, ASCII art, or other indented block; it's
+	// skipped.
+	Formatted
+)
+
+var kindNames = []string{"prose", "verse", "formatted"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// VerseResultor may be optionally implemented by a BodyResultor to receive
+// verse paragraphs line by line: BeginVerse is called before the tokens of a
+// verse paragraph, EndLine after those of each of its lines, and then
+// EndParagraph ends the stanza. Otherwise verse is tokenized as prose.
+type VerseResultor interface {
+	BeginVerse() error
+	EndLine() error
+}
+
+// tablePat matches lines that look like part of a table: column rules, or
+// runs of space separating columns.
+var tablePat = regexp.MustCompile(`[|+]|-{3,}|={3,}|_{3,}|\.{4,}|\S {3,}\S`)
+
+// maxVerseLine is the maximum length, in runes, of any line of verse other
+// than the last one of its stanza; wrapped prose lines run longer.
+const maxVerseLine = 60
+
+// minVerseLines is the minimum number of lines in a stanza, and minVerseAvg
+// the minimum average length of its lines, in runes; fewer or shorter lines
+// are more likely headings, addresses, or lists.
+const (
+	minVerseLines = 3
+	minVerseAvg   = 16
+)
+
+// classify classifies a paragraph of lines.
+func classify(lines [][]byte) Kind {
+	switch {
+	case isTable(lines):
+		return Formatted
+	case isVerse(lines):
+		return Verse
+	}
+	for _, bline := range lines {
+		if !isFormatted(bline) {
+			return Prose
+		}
+	}
+	return Formatted
+}
+
+// isTable returns true if most lines look like part of a table, or if the
+// paragraph is mostly symbols, e.g. ASCII art.
+func isTable(lines [][]byte) bool {
+	if mostly(lines, tablePat) {
+		return true
+	}
+	letters, other := 0, 0
+	for _, bline := range lines {
+		for _, r := range string(bline) {
+			switch {
+			case unicode.IsLetter(r):
+				letters++
+			case !unicode.IsSpace(r):
+				other++
+			}
+		}
+	}
+	return letters < other
+}
+
+// isVerse returns true for paragraphs of at least minVerseLines short, but
+// not too short, lines, (nearly) all of which start with a capital letter,
+// as verse traditionally does.
+func isVerse(lines [][]byte) bool {
+	if len(lines) < minVerseLines {
+		return false
+	}
+	caps, total := 0, 0
+	for i, bline := range lines {
+		bline = bytes.TrimSpace(bline)
+		n := utf8.RuneCount(bline)
+		if i < len(lines)-1 && n > maxVerseLine {
+			return false
+		}
+		total += n
+		if startsUpper(bline) {
+			caps++
+		}
+	}
+	return total >= minVerseAvg*len(lines) && 4*caps >= 3*len(lines)
+}
+
+// startsUpper returns true if the first letter of line, after any leading
+// punctuation, is upper case.
+func startsUpper(line []byte) bool {
+	for _, r := range string(line) {
+		if unicode.IsLetter(r) {
+			return unicode.IsUpper(r)
+		}
+		if !unicode.IsPunct(r) {
+			return false
+		}
+	}
+	return false
+}
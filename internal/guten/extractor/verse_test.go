@@ -0,0 +1,57 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		para string
+		want Kind
+	}{
+		{"prose", `
+It was a long road to the sea, and the walkers went along it slowly,
+talking of nothing in particular while the sun went down behind them.`, Prose},
+		{"short prose", `
+She slept.`, Prose},
+		{"verse", `
+Tyger Tyger, burning bright,
+In the forests of the night;
+What immortal hand or eye,
+Could frame thy fearful symmetry?`, Verse},
+		{"indented verse", `
+    In what distant deeps or skies
+      Burnt the fire of thine eyes?
+    On what wings dare he aspire?`, Verse},
+		{"heading", `
+CHAPTER I.
+The Beginning`, Prose},
+		{"address", `
+Mr. John Smith,
+Baker Street,
+London.`, Prose},
+		{"block quote", `
+    This is an indented block quote of prose that runs on for quite a
+    while, as block quotes do, and is not verse at all in any sense.`, Formatted},
+		{"table", `
+    | Name   | Age |
+    +--------+-----+
+    | Tom    | 12  |`, Formatted},
+		{"ascii art", `
+      /\_/\
+     ( o.o )
+      > ^ <`, Formatted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var lines [][]byte
+			for _, line := range strings.Split(strings.TrimPrefix(tc.para, "\n"), "\n") {
+				lines = append(lines, []byte(line))
+			}
+			if got := classify(lines); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
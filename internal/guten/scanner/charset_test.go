@@ -0,0 +1,59 @@
+package scanner
+
+import "testing"
+
+func TestDecoder(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		declare string
+		lines   []string
+		want    []string
+	}{
+		{
+			name:  "undeclared utf-8",
+			lines: []string{"résumé", "naïve"},
+			want:  []string{"résumé", "naïve"},
+		},
+		{
+			name:  "undeclared stray byte",
+			lines: []string{"caf\xe9", "résumé"},
+			want:  []string{"café", "résumé"},
+		},
+		{
+			name:    "declared utf-8 stray byte",
+			declare: "UTF-8",
+			lines:   []string{"bad \xff byte", "résumé"},
+			want:    []string{"bad � byte", "résumé"},
+		},
+		{
+			name:    "declared ascii",
+			declare: "ASCII",
+			lines:   []string{"caf\xe9", "plain"},
+			want:    []string{"café", "plain"},
+		},
+		{
+			name:    "declared latin-1",
+			declare: "ISO-8859-1",
+			lines:   []string{"caf\xe9", "\xabquoted\xbb"},
+			want:    []string{"café", "«quoted»"},
+		},
+		{
+			name:    "declared cp1252",
+			declare: "Windows-1252",
+			lines:   []string{"\x93quoted\x94"},
+			want:    []string{"“quoted”"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var dec decoder
+			if tc.declare != "" {
+				dec.declare(tc.declare)
+			}
+			for i, line := range tc.lines {
+				if got := string(dec.decode([]byte(line))); got != tc.want[i] {
+					t.Errorf("line %d: got %q, want %q", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
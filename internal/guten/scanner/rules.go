@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// Rules configure how a Scanner recognizes the structure of a document, so
+// that it may scan plain-text corpora other than Project Gutenberg.
+type Rules struct {
+	// Boundaries are the variants of lines that start or end the body, in
+	// order of precedence.
+	Boundaries []BoundaryRule
+
+	// Mark matches any other structural mark line; its first submatch is
+	// passed to Resultor.Mark. Mark lines also end the meta section.
+	Mark *regexp.Regexp
+
+	// Meta matches a "key: value" meta line, its first two submatches being
+	// the key and value.
+	Meta *regexp.Regexp
+
+	// Continuation, if not nil, returns the continued part of a meta value
+	// from a following line; off is the offset of the value in its first
+	// line.
+	Continuation func(line []byte, off int) ([]byte, bool)
+
+	// SlugName extracts the document name from its first submatch in the
+	// slug; the name is used by boundaries that don't have their own.
+	SlugName *regexp.Regexp
+
+	// EncodingKey is the meta key declaring the character set encoding.
+	EncodingKey string
+
+	// Boilerplate, if not nil, matches lines outside the body of a document
+	// without any start boundary; see Scanner.Scan. If nil, such documents
+	// have no body.
+	Boilerplate *regexp.Regexp
+}
+
+// BoundaryRule is a variant of the lines that start or end the body of a
+// document; its pattern is matched against whole trimmed lines, and its
+// first submatch, if any, is the name of the document.
+type BoundaryRule struct {
+	End     bool
+	Pattern *regexp.Regexp
+}
+
+// IndentContinuation continues meta values onto following lines indented as
+// far as the value.
+func IndentContinuation(line []byte, off int) ([]byte, bool) {
+	if len(line) < off || len(bytes.TrimSpace(line[:off])) != 0 {
+		return nil, false
+	}
+	return line[off:], true
+}
+
+// GutenbergRules are the default Rules, for Project Gutenberg e-books; see
+// testdata for samples of the boundary variants.
+var GutenbergRules = Rules{
+	Boundaries: []BoundaryRule{
+		// e.g. "*** START OF THIS PROJECT GUTENBERG EBOOK TITLE ***", or since
+		// 2020 "*** START OF THE PROJECT GUTENBERG EBOOK TITLE ***"
+		{false, regexp.MustCompile(`(?i)^\*{3}\s*START OF (?:THIS|THE) PROJECT GUTENBERG E-?(?:BOOK|TEXT),?\s*(.*?)\s*\*{3}$`)},
+		{true, regexp.MustCompile(`(?i)^\*{3}\s*END OF (?:THIS|THE) PROJECT GUTENBERG E-?(?:BOOK|TEXT),?\s*(.*?)\s*\*{3}$`)},
+
+		// legacy, ending the license before the body, e.g.
+		// "*END*THE SMALL PRINT! FOR PUBLIC DOMAIN ETEXTS*Ver.04.29.93*END*"
+		{false, regexp.MustCompile(`(?i)^\*END\*?\s*THE SMALL PRINT!`)},
+
+		// legacy, e.g. "End of the Project Gutenberg EBook of Title, by Author",
+		// "End of Project Gutenberg's Title, by Author", or
+		// "*END OF THE PROJECT GUTENBERG ETEXT OF TITLE*"
+		{true, regexp.MustCompile(`(?i)^\*{0,3}\s*END OF (?:THIS |THE )?PROJECT GUTENBERG(?:'S)?\s+(?:E-?(?:BOOK|TEXT)\s+(?:OF\s+)?)?(.*?)(?:,\s+BY\s+.*?)?\s*\*{0,3}$`)},
+	},
+	Mark:         regexp.MustCompile(`^\*{3}\s*(.+?)\s*\*{3}$`),
+	Meta:         regexp.MustCompile(`^(.+?): (.*)$`),
+	Continuation: IndentContinuation,
+	SlugName:     regexp.MustCompile(`(?i)project gutenberg(?:'s)?\s+(?:e-?(?:book|text)\s+)?of\s+(.+?)(?:,\s+by\s+.*)?$`),
+	EncodingKey:  "Character set encoding",
+	Boilerplate:  regexp.MustCompile(`(?i)gutenberg`),
+}
+
+// gutenbergEncodingPat is the encodingPat of GutenbergRules.
+var gutenbergEncodingPat = GutenbergRules.encodingPat()
+
+// encodingPat returns a pattern matching the encoding declaration in the
+// head of a stream.
+func (rules Rules) encodingPat() *regexp.Regexp {
+	if rules.EncodingKey == "" {
+		return nil
+	}
+	return regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(rules.EncodingKey) + `: *([^\r\n]+)`)
+}
+
+// Option customizes a Scanner constructed by New.
+type Option func(*Scanner)
+
+// WithRules sets the rules by which the Scanner recognizes document
+// structure, rather than GutenbergRules.
+func WithRules(rules Rules) Option {
+	encPat := rules.encodingPat()
+	return func(sc *Scanner) {
+		sc.rules = rules
+		sc.encPat = encPat
+	}
+}
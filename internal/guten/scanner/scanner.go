@@ -3,26 +3,20 @@ package scanner
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"io"
 	"regexp"
 )
 
-// TODO: support meta "Character set encoding"
-// 6923 'ASCII'
-// 5742 'ISO-8859-1'
-//  709 'UTF-8'
-//   83 'US-ASCII'
-//   21 'ISO
-//    5 'Unicode
-//    5 'ISO-8859-2'
-//    2 'ISO-8859-3'
-//    2 'ISO-8859-15'
-//    2 'CP-1252'
-
-var (
-	markPat     = regexp.MustCompile(`^\*{3}\s+(.+?)\s+\*{3}$`)
-	standEndPat = regexp.MustCompile(`(?:START|(END)) OF THIS PROJECT GUTENBERG EBOOK (.+)$`)
-)
+// sniffSize is how much of the stream New searches for a character set
+// encoding header.
+const sniffSize = 16 * 1024
+
+// maxFallbackSize is how much of a document is held for the fallback, until a
+// start boundary is found; longer documents without one have no body.
+const maxFallbackSize = 32 << 20
+
+var errTooLong = errors.New("no start boundary, and too long to find the body by fallback")
 
 // Resultor is the interface implemnetd to receive results from Scanner.
 type Resultor interface {
@@ -34,23 +28,62 @@ type Resultor interface {
 	Close() error
 }
 
-// Scanner scans a Project Gutenberg e-book.
+// Scanner scans a Project Gutenberg e-book, or another document by its Rules.
 type Scanner struct {
-	sc  *bufio.Scanner
-	res Resultor
+	sc     *bufio.Scanner
+	cur    []byte
+	dec    *decoder
+	res    Resultor
+	rules  Rules
+	encPat *regexp.Regexp
+
+	// name is the e-book name from the slug, used for boundaries that
+	// don't name it
+	name string
+
+	// lines holds all scanned lines until a start boundary is found, in
+	// case there's none, and the body must be found by fallback instead;
+	// buffered counts their bytes, up to maxFallbackSize
+	lines    [][]byte
+	buffered int
+	bounded  bool
 }
 
 // New creates a new Scanner that will scan from the given io.Reader and call
-// methods on the given resultor.
-func New(r io.Reader, res Resultor) *Scanner {
-	return &Scanner{
-		sc:  bufio.NewScanner(r),
-		res: res,
+// methods on the given resultor, by GutenbergRules unless WithRules is given.
+// Lines are transcoded to UTF-8 as declared by any "Character set encoding"
+// header; otherwise any line that isn't valid UTF-8 is decoded as CP-1252,
+// unless UTF-8 was declared. See Decodable for the supported encodings.
+func New(r io.Reader, res Resultor, opts ...Option) *Scanner {
+	sc := &Scanner{
+		dec:    &decoder{},
+		res:    res,
+		rules:  GutenbergRules,
+		encPat: gutenbergEncodingPat,
+	}
+	for _, opt := range opts {
+		opt(sc)
+	}
+
+	// the header may be preceded by lines in its encoding, so look ahead for it
+	br := bufio.NewReaderSize(r, sniffSize)
+	if pat := sc.encPat; pat != nil {
+		head, _ := br.Peek(sniffSize) // a short head is fine, any error will recur
+		if m := pat.FindSubmatch(head); len(m) > 0 {
+			sc.dec.declare(string(m[1]))
+		}
 	}
+	sc.sc = bufio.NewScanner(br)
+	return sc
+}
+
+// line returns the current line, decoded to UTF-8 once by scan.
+func (sc *Scanner) line() []byte {
+	return sc.cur
 }
 
 // Scan performs the scan.
-func (sc Scanner) Scan() (err error) {
+func (sc *Scanner) Scan() (err error) {
 	defer func() {
 		if cerr := sc.res.Close(); err == nil {
 			err = cerr
@@ -65,28 +98,115 @@ func (sc Scanner) Scan() (err error) {
 			return err
 		}
 	}
-	return sc.sc.Err()
+	if err := sc.sc.Err(); err != nil {
+		return err
+	}
+	if !sc.bounded && sc.rules.Boilerplate != nil {
+		if sc.buffered > maxFallbackSize {
+			return errTooLong
+		}
+		return sc.fallback()
+	}
+	return nil
 }
 
-func (sc Scanner) handleMark() (bool, error) {
-	m := markPat.FindSubmatch(sc.sc.Bytes())
-	if len(m) == 0 {
-		return false, nil
+// scan scans the next line, holding onto it until a start boundary is found.
+func (sc *Scanner) scan() bool {
+	if !sc.sc.Scan() {
+		sc.cur = nil
+		return false
 	}
+	sc.cur = sc.dec.decode(sc.sc.Bytes())
+	if !sc.bounded && sc.buffered <= maxFallbackSize {
+		if sc.buffered += len(sc.cur); sc.buffered > maxFallbackSize {
+			sc.lines = nil
+		} else {
+			sc.lines = append(sc.lines, append([]byte(nil), sc.line()...))
+		}
+	}
+	return true
+}
 
-	if sem := standEndPat.FindSubmatch(m[1]); len(sem) > 0 {
-		end := len(sem[1]) > 0
-		return true, sc.res.Boundary(end, string(sem[2]))
+// redecode decodes the current line again, after an encoding declaration.
+func (sc *Scanner) redecode() {
+	sc.cur = sc.dec.decode(sc.sc.Bytes())
+	if !sc.bounded && len(sc.lines) > 0 {
+		sc.lines[len(sc.lines)-1] = append([]byte(nil), sc.cur...)
 	}
+}
 
-	// XXX e.g. "*** START: FULL LICENSE ***"
-	return true, sc.res.Mark(string(m[1]))
+// fallback handles a document without any start boundary, replaying all of
+// its lines: the body starts after the last paragraph in the first half with
+// a Boilerplate line (e.g. mentioning Project Gutenberg), and ends at the
+// first such line in the second half.
+func (sc *Scanner) fallback() error {
+	lines := sc.lines
+	sc.lines = nil
+	start, end := 0, len(lines)
+	for i, line := range lines[:end/2] {
+		if sc.rules.Boilerplate.Match(line) {
+			start = i + 1
+		}
+	}
+	for start < end && len(bytes.TrimSpace(lines[start])) > 0 {
+		start++
+	}
+	for i := len(lines) / 2; i < len(lines); i++ {
+		if sc.rules.Boilerplate.Match(lines[i]) {
+			end = i
+			break
+		}
+	}
+
+	if err := sc.res.Boundary(false, sc.name); err != nil {
+		return err
+	}
+	for _, line := range lines[start:end] {
+		if err := sc.res.Data(line); err != nil {
+			return err
+		}
+	}
+	if end < len(lines) {
+		return sc.res.Boundary(true, sc.name)
+	}
+	return nil
 }
 
-func (sc Scanner) scanMeta() error {
+func (sc *Scanner) handleMark() (bool, error) {
+	line := bytes.TrimSpace(sc.line())
+	for _, rule := range sc.rules.Boundaries {
+		m := rule.Pattern.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if rule.End && !sc.bounded {
+			// no start, leave it to the fallback
+			return false, nil
+		}
+		name := sc.name
+		if len(m) > 1 && len(m[1]) > 0 {
+			name = string(m[1])
+		}
+		if !rule.End {
+			sc.bounded = true
+			sc.lines = nil
+		}
+		return true, sc.res.Boundary(rule.End, name)
+	}
 
-	// scan key
-	for sc.sc.Scan() {
+	// XXX e.g. "*** START: FULL LICENSE ***"
+	if sc.rules.Mark == nil {
+		return false, nil
+	}
+	if m := sc.rules.Mark.FindSubmatch(line); len(m) > 0 {
+		return true, sc.res.Mark(string(m[1]))
+	}
+	return false, nil
+}
+
+func (sc *Scanner) scanMeta() error {
+	more := sc.scan()
+	for more {
 		// mark ends meta section
 		if mark, err := sc.handleMark(); err != nil {
 			return err
@@ -95,59 +215,71 @@ func (sc Scanner) scanMeta() error {
 		}
 
 		// skip blank lines
-		bline := sc.sc.Bytes()
+		bline := sc.line()
 		if t := bytes.TrimSpace(bline); len(t) == 0 {
+			more = sc.scan()
 			continue
 		}
 
 		// detect key: val
-		off := bytes.Index(bline, []byte(": "))
+		var m []int
+		if sc.rules.Meta != nil {
+			m = sc.rules.Meta.FindSubmatchIndex(bline)
+		}
 
-		if off <= 0 {
+		if m == nil {
 			// emit non key-val
-			if err := sc.res.Data(sc.sc.Bytes()); err != nil {
+			if err := sc.res.Data(bline); err != nil {
 				return err
 			}
+			more = sc.scan()
 			continue
 		}
 
-		key := string(bline[:off])
+		key := string(bline[m[2]:m[3]])
 
-		// continue scanning val
-		off += 2 // len(": ")
-		val := string(bline[off:])
-		for sc.sc.Scan() {
-			if bline := sc.sc.Bytes(); len(bline) >= off && len(bytes.TrimSpace(bline[:off])) == 0 {
-				val += "\n" + string(bline[off:])
-				continue
+		// continue scanning val, up to the next line, which may be a mark
+		off := m[4]
+		val := string(bline[off:m[5]])
+		for more = sc.scan(); more && sc.rules.Continuation != nil; more = sc.scan() {
+			cont, ok := sc.rules.Continuation(sc.line(), off)
+			if !ok {
+				break
 			}
-			if err := sc.res.Meta(key, val); err != nil {
-				return err
+			val += "\n" + string(cont)
+		}
+		if key == sc.rules.EncodingKey {
+			sc.dec.declare(val)
+			if more {
+				// the next line was already decoded without the declaration
+				sc.redecode()
 			}
-			break
+		}
+		if err := sc.res.Meta(key, val); err != nil {
+			return err
 		}
 	}
 
 	return sc.sc.Err()
 }
 
-func (sc Scanner) scanBody() error {
-	for sc.sc.Scan() {
+func (sc *Scanner) scanBody() error {
+	for sc.scan() {
 		// handle marks
 		if _, err := sc.handleMark(); err != nil {
 			return err
 		}
-		if err := sc.res.Data(sc.sc.Bytes()); err != nil {
+		if err := sc.res.Data(sc.line()); err != nil {
 			return err
 		}
 	}
 	return sc.sc.Err()
 }
 
-func (sc Scanner) scanFirst() error {
+func (sc *Scanner) scanFirst() error {
 	var first string
-	for sc.sc.Scan() {
-		t := bytes.TrimSpace(sc.sc.Bytes())
+	for sc.scan() {
+		t := bytes.TrimSpace(sc.line())
 		if len(t) == 0 {
 			break
 		}
@@ -157,5 +289,10 @@ func (sc Scanner) scanFirst() error {
 			first = string(t)
 		}
 	}
+	if pat := sc.rules.SlugName; pat != nil {
+		if m := pat.FindStringSubmatch(first); len(m) > 1 {
+			sc.name = m[1]
+		}
+	}
 	return sc.res.Slug(first)
 }
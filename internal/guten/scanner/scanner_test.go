@@ -0,0 +1,172 @@
+package scanner_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jcorbin/markov/internal/guten/extractor"
+	"github.com/jcorbin/markov/internal/guten/scanner"
+)
+
+// brightDay is the body of every testdata sample, as tokens; the second
+// paragraph is only ended by the end of the body.
+var brightDay = []string{
+	"It was a bright cold day in April , and the clocks were striking thirteen . " +
+		"The hallway smelt of boiled cabbage and old rag mats .",
+	"At one end of it a coloured poster , too large for indoor display , had been " +
+		"tacked to the wall .",
+}
+
+func TestBoundaryVariants(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		bounds []string
+	}{
+		// the plain end line comes before the end mark; both are reported
+		{"this-ebook", []string{"start A BRIGHT DAY", "end A Bright Day", "end A BRIGHT DAY"}},
+		{"the-ebook", []string{"start A BRIGHT DAY", "end A BRIGHT DAY"}},
+		{"etext", []string{"start A BRIGHT DAY", "end A Bright Day"}},
+		{"small-print", []string{"start A Bright Day", "end A BRIGHT DAY"}},
+		{"small-print-start", []string{"start A Bright Day", "start A BRIGHT DAY", "end A BRIGHT DAY"}},
+		{"no-marks", []string{"start A Bright Day", "end A Bright Day"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tc.name+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var body bodyRecorder
+			rec := boundaryRecorder{Resultor: extractor.New(&body)}
+			if err := scanner.New(f, &rec).Scan(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rec.bounds, tc.bounds) {
+				t.Errorf("got boundaries %q, want %q", rec.bounds, tc.bounds)
+			}
+			if !reflect.DeepEqual(body.paras, brightDay) {
+				t.Errorf("got paragraphs %q, want %q", body.paras, brightDay)
+			}
+			if n := body.info["EBOOK_NUMBER"]; n != "1000" {
+				t.Errorf("got e-book number %q, want 1000", n)
+			}
+		})
+	}
+}
+
+// boundaryRecorder records boundaries passed on to a scanner.Resultor.
+type boundaryRecorder struct {
+	scanner.Resultor
+	bounds []string
+}
+
+func (br *boundaryRecorder) Boundary(end bool, name string) error {
+	kind := "start"
+	if end {
+		kind = "end"
+	}
+	br.bounds = append(br.bounds, fmt.Sprintf("%s %s", kind, name))
+	return br.Resultor.Boundary(end, name)
+}
+
+// bodyRecorder records meta info, and body paragraphs as space separated
+// tokens.
+type bodyRecorder struct {
+	info  map[string]string
+	toks  []string
+	paras []string
+}
+
+func (br *bodyRecorder) SetTitle(string) error { return nil }
+func (br *bodyRecorder) Close() error          { return nil }
+
+func (br *bodyRecorder) SetInfo(info map[string]string) error {
+	br.info = info
+	return nil
+}
+
+func (br *bodyRecorder) OnToken(tok []byte) error {
+	br.toks = append(br.toks, string(tok))
+	return nil
+}
+
+func (br *bodyRecorder) EndParagraph() error {
+	br.paras = append(br.paras, strings.Join(br.toks, " "))
+	br.toks = br.toks[:0]
+	return nil
+}
+
+func TestCustomRules(t *testing.T) {
+	rules := scanner.Rules{
+		Boundaries: []scanner.BoundaryRule{
+			{End: false, Pattern: regexp.MustCompile(`^=== BEGIN TEXT: (.+) ===$`)},
+			{End: true, Pattern: regexp.MustCompile(`^=== END TEXT ===$`)},
+		},
+		Mark:         regexp.MustCompile(`^--- (.+) ---$`),
+		Meta:         regexp.MustCompile(`^(\w+) = (.*)$`),
+		Continuation: scanner.IndentContinuation,
+		SlugName:     regexp.MustCompile(`^Chronicle of (.+)$`),
+		EncodingKey:  "Charset",
+	}
+	doc := strings.Join([]string{
+		"Chronicle of the Old Mill",
+		"",
+		"Name = The Old Mill",
+		"Charset = ISO-8859-2",
+		"Keeper = J. Doe",
+		"         and K. Roe",
+		"=== BEGIN TEXT: The Old Mill ===",
+		"\xa3\xf3d\xbc was far.",
+		"--- SECTION ---",
+		"=== END TEXT ===",
+		"*** END OF THE PROJECT GUTENBERG EBOOK THE OLD MILL ***",
+	}, "\n")
+
+	var rec eventRecorder
+	if err := scanner.New(strings.NewReader(doc), &rec, scanner.WithRules(rules)).Scan(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"slug Chronicle of the Old Mill",
+		"meta Name=The Old Mill",
+		"meta Charset=ISO-8859-2",
+		"meta Keeper=J. Doe\nand K. Roe",
+		"start The Old Mill",
+		"data Łódź was far.",
+		"mark SECTION",
+		"data --- SECTION ---",
+		"end the Old Mill",
+		"data === END TEXT ===",
+		"data *** END OF THE PROJECT GUTENBERG EBOOK THE OLD MILL ***",
+		"close",
+	}
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Errorf("got events:\n%q\nwant:\n%q", rec.events, want)
+	}
+}
+
+// eventRecorder records every call to a scanner.Resultor.
+type eventRecorder struct{ events []string }
+
+func (er *eventRecorder) record(format string, args ...interface{}) error {
+	er.events = append(er.events, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (er *eventRecorder) Slug(s string) error        { return er.record("slug %s", s) }
+func (er *eventRecorder) Meta(key, val string) error { return er.record("meta %s=%s", key, val) }
+func (er *eventRecorder) Mark(s string) error        { return er.record("mark %s", s) }
+func (er *eventRecorder) Data(buf []byte) error      { return er.record("data %s", buf) }
+func (er *eventRecorder) Close() error               { return er.record("close") }
+func (er *eventRecorder) Boundary(end bool, name string) error {
+	if end {
+		return er.record("end %s", name)
+	}
+	return er.record("start %s", name)
+}
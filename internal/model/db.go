@@ -2,9 +2,12 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"math/rand"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/jcorbin/markov/internal/symbol"
 )
@@ -12,30 +15,92 @@ import (
 // DocDB represents a database of extracted documents. It contains a markov
 // language for generated a plausible document title, and an inverted index to
 // map back to supporting document info from such a title.
+//
+// The inverted index lists a document once for each occurrence of a word in
+// its title, skipping StopWords; TitleIDF holds the inverse document frequency
+// of each indexed word. Documents are also indexed by author, language and
+// subject, and there may be per-author and per-subject title languages.
 type DocDB struct {
 	Docs      map[string]DocInfo  `json:"docs"`
 	TitleLang Lang                `json:"titleLang"`
 	InvTW     map[string][]string `json:"invertedTitleWords"`
+	StopWords []string            `json:"stopWords,omitempty"`
+	TitleIDF  map[string]float64  `json:"titleIDF,omitempty"`
+	Authors   DocIndex            `json:"authors,omitempty"`
+	Languages DocIndex            `json:"languages,omitempty"`
+	Subjects  DocIndex            `json:"subjects,omitempty"`
+
+	AuthorTitleLangs  map[string]Lang `json:"authorTitleLangs,omitempty"`
+	SubjectTitleLangs map[string]Lang `json:"subjectTitleLangs,omitempty"`
+
+	// Alternates holds other editions of documents, by document id, that
+	// were left out of Docs as duplicates.
+	Alternates map[string][]DocInfo `json:"alternates,omitempty"`
+}
+
+// AddAlternate records another edition of the identified document.
+func (db *DocDB) AddAlternate(id string, di DocInfo) {
+	if db.Alternates == nil {
+		db.Alternates = make(map[string][]DocInfo)
+	}
+	db.Alternates[id] = append(db.Alternates[id], di)
 }
 
-// DocInfo contains meta data for a documnet in a DocDB.
+// DefaultStopWords are common title words that are too frequent to be useful
+// in the inverted title index.
+var DefaultStopWords = []string{
+	"a", "an", "and", "as", "at", "by", "for", "from", "in", "into", "is",
+	"it", "of", "on", "or", "the", "to", "with",
+}
+
+// DocInfo contains meta data for a documnet in a DocDB; SourceMember is the
+// path of the document within SourceFile, if it's an archive.
 type DocInfo struct {
-	SourceFile string            `json:"sourceFile"`
-	TransFile  string            `json:"transFile"`
-	Title      string            `json:"title"`
-	Info       map[string]string `json:"info"`
+	SourceFile   string            `json:"sourceFile"`
+	SourceMember string            `json:"sourceMember,omitempty"`
+	TransFile    string            `json:"transFile"`
+	Title        string            `json:"title"`
+	Info         map[string]string `json:"info"`
+	Words        int               `json:"words,omitempty"`
+
+	// MinHash is a signature of the document text, used while mining to
+	// find duplicate editions.
+	MinHash MinHash `json:"-"`
 }
 
-// Doc represents an extracted document loaded from a DocInfo in a DocDB.
+// Doc represents an extracted document loaded from a DocInfo in a DocDB;
+// Verse is the language of any verse in the document, kept apart from its
+// prose.
 type Doc struct {
 	Title string            `json:"title"`
 	Info  map[string]string `json:"info"`
 	Lang  Lang              `json:"language"`
+	Verse *Lang             `json:"verse,omitempty"`
 }
 
-// SupportDocIDs is a set of supporting document ids mapped to their longest
-// supporting word.
-type SupportDocIDs map[string]string
+// DocName returns a display name for the identified document: its title and
+// id, e.g. "Pride and Prejudice [#1342]"; ids are Gutenberg e-book numbers,
+// but titles are not unique.
+func (db DocDB) DocName(id string) string {
+	if title := db.Docs[id].Title; title != "" && title != id {
+		return fmt.Sprintf("%s [#%s]", title, id)
+	}
+	return id
+}
+
+// SupportDoc describes how a document supports a title: Score is the TF-IDF
+// relevance of the document's title to it, and Word is the title word that
+// contributed most to that score. Reason records why the document was
+// chosen, if it's been through SupportDocIDs.Select.
+type SupportDoc struct {
+	Word   string
+	Score  float64
+	Reason string
+}
+
+// SupportDocIDs is a set of supporting document ids mapped to how they
+// support a title.
+type SupportDocIDs map[string]SupportDoc
 
 // SortedIDs returns the supporting IDs in sorted order.
 func (sdids SupportDocIDs) SortedIDs() []string {
@@ -47,47 +112,207 @@ func (sdids SupportDocIDs) SortedIDs() []string {
 	return ids
 }
 
+// Ranked returns the supporting IDs in descending order of Score.
+func (sdids SupportDocIDs) Ranked() []string {
+	ids := sdids.SortedIDs()
+	sort.SliceStable(ids, func(i, j int) bool {
+		return sdids[ids[i]].Score > sdids[ids[j]].Score
+	})
+	return ids
+}
+
 // GenTitle generates a random document title, and returns a set of supporting
 // document ids.
 func (db DocDB) GenTitle(rng *rand.Rand) (string, SupportDocIDs) {
-	title, docs := "", make(map[string]string)
-	_ = db.TitleLang.Trans.GenChain(rng, func(sym symbol.Symbol) error {
-		if sym == 0 {
-			return nil
+	title, docs, _ := db.GenTitleFrom(rng, nil, nil)
+	return title, docs
+}
+
+// GenTitleFrom is like GenTitle, but the title begins with the given start
+// words, and chaining is biased by the given bias function (which may be
+// nil). Fails if any start word is not in the title dictionary.
+func (db DocDB) GenTitleFrom(rng *rand.Rand, start []string, bias Bias) (string, SupportDocIDs, error) {
+	syms, err := db.TitleLang.Symbolize(start)
+	if err != nil {
+		return "", nil, err
+	}
+	var last symbol.Symbol
+	if len(syms) > 0 {
+		last = syms[len(syms)-1]
+	}
+	_ = db.TitleLang.Trans.GenBiasedChainFrom(rng, last, bias, func(sym symbol.Symbol) error {
+		if sym != 0 {
+			syms = append(syms, sym)
 		}
-		word := db.TitleLang.Dict.ToString(sym)
+		return nil
+	})
+	title, docs := db.titleFor(syms)
+	return title, docs, nil
+}
+
+// GenTitleTo generates a random document title that begins with the given
+// start words (if any) and ends with the given end words, by bridging the two
+// with GenFill through the title language and its reversal rev (see
+// Lang.Reversed). Returns an empty title if no bridge was found within
+// maxLen words. Fails if any start or end word is not in the title
+// dictionary.
+func (db DocDB) GenTitleTo(rng *rand.Rand, rev Lang, start, end []string, maxLen int) (string, SupportDocIDs, error) {
+	ssyms, err := db.TitleLang.Symbolize(start)
+	if err != nil {
+		return "", nil, err
+	}
+	esyms, err := db.TitleLang.Symbolize(end)
+	if err != nil {
+		return "", nil, err
+	}
+	var a, b symbol.Symbol
+	if len(ssyms) > 0 {
+		a = ssyms[len(ssyms)-1]
+	}
+	if len(esyms) > 0 {
+		b = esyms[0]
+	}
+	bridge, ok := GenFill(rng, db.TitleLang.Trans, rev.Trans, a, b, maxLen)
+	if !ok {
+		return "", nil, nil
+	}
+	syms := append(append(ssyms, bridge...), esyms...)
+	title, docs := db.titleFor(syms)
+	return title, docs, nil
+}
+
+// titleFor returns the title string, and supporting document ids, for a
+// chain of title symbols.
+func (db DocDB) titleFor(syms []symbol.Symbol) (string, SupportDocIDs) {
+	words := make([]string, len(syms))
+	for i, sym := range syms {
+		words[i] = db.TitleLang.Dict.ToString(sym)
+	}
+	return strings.Join(words, " "), db.TitleDocs(words)
+}
+
+// TitleDocs returns the ids of documents whose titles contain any of the
+// given (non stop) words, scored by TF-IDF relevance.
+func (db DocDB) TitleDocs(words []string) SupportDocIDs {
+	stop := db.stopWords()
+	seen := make(map[string]bool, len(words))
+	docs := make(SupportDocIDs)
+	best := make(map[string]float64)
+	for _, word := range words {
+		if stop[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		idf := db.IDF(word)
+		tf := make(map[string]int)
 		for _, id := range db.InvTW[word] {
-			if len(word) > len(docs[id]) {
-				docs[id] = word
-			}
+			tf[id]++
 		}
-		if title == "" {
-			title = word
-		} else {
-			title += " " + word
+		for id, n := range tf {
+			w := float64(n) * idf
+			sd := docs[id]
+			sd.Score += w
+			if sd.Word == "" || w > best[id] {
+				sd.Word, best[id] = word, w
+			}
+			docs[id] = sd
 		}
-		return nil
-	})
-	return title, docs
+	}
+	return docs
+}
+
+// IDF returns the inverse document frequency of a title word: the log of
+// the ratio of all documents to those whose title contains the word.
+func (db DocDB) IDF(word string) float64 {
+	if idf, def := db.TitleIDF[word]; def {
+		return idf
+	}
+	return titleIDF(len(db.Docs), db.InvTW[word])
+}
+
+func titleIDF(n int, ids []string) float64 {
+	df := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		df[id] = struct{}{}
+	}
+	if len(df) == 0 {
+		return 0
+	}
+	return math.Log(float64(n) / float64(len(df)))
+}
+
+// ComputeIDF (re)computes TitleIDF from the inverted title index.
+func (db *DocDB) ComputeIDF() {
+	db.TitleIDF = make(map[string]float64, len(db.InvTW))
+	for word, ids := range db.InvTW {
+		db.TitleIDF[word] = titleIDF(len(db.Docs), ids)
+	}
+}
+
+func (db DocDB) stopWords() map[string]bool {
+	stop := make(map[string]bool, len(db.StopWords))
+	for _, word := range db.StopWords {
+		stop[word] = true
+	}
+	return stop
 }
 
 // MergedDocLang returns a new language made by merging together all
 // constituent language from the supporting document ids.
-func (db DocDB) MergedDocLang(sup SupportDocIDs) (lng Lang, err error) {
+func (db DocDB) MergedDocLang(sup SupportDocIDs) (Lang, error) {
+	ml, err := db.mergeDocLangs(sup, false, false)
+	return ml.Lang, err
+}
+
+// MergedDocVerse is like MergedDocLang, but merges the verse languages of
+// the supporting documents instead; fails if none of them have any verse.
+func (db DocDB) MergedDocVerse(sup SupportDocIDs) (Lang, error) {
+	ml, err := db.mergeDocLangs(sup, false, true)
+	return ml.Lang, err
+}
+
+// MergedDocSources is like MergedDocLang, but retains each supporting
+// document's language, so that the merged transitions may be traced back to
+// their source documents.
+func (db DocDB) MergedDocSources(sup SupportDocIDs) (MergedLang, error) {
+	return db.mergeDocLangs(sup, true, false)
+}
+
+// MergedVerseSources is like MergedDocSources, but for verse languages; see
+// MergedDocVerse.
+func (db DocDB) MergedVerseSources(sup SupportDocIDs) (MergedLang, error) {
+	return db.mergeDocLangs(sup, true, true)
+}
+
+func (db DocDB) mergeDocLangs(sup SupportDocIDs, keep, verse bool) (ml MergedLang, err error) {
 	// TODO: parallelism / cache
 	ids := sup.SortedIDs()
 	for _, id := range ids {
 		doc, err := db.Docs[id].Load()
 		if err != nil {
-			return lng, err
+			return ml, err
 		}
-		if lng.Dict == nil {
-			lng = doc.Lang
+		lng := doc.Lang
+		if verse {
+			if doc.Verse == nil {
+				continue
+			}
+			lng = *doc.Verse
+		}
+		if keep {
+			ml.IDs = append(ml.IDs, id)
+			ml.Parts = append(ml.Parts, lng)
+		}
+		if ml.Dict == nil {
+			ml.Lang = lng
 			continue
 		}
-		lng = lng.Merge(doc.Lang)
+		ml.Lang = ml.Lang.Merge(lng)
+	}
+	if verse && ml.Dict == nil {
+		return ml, fmt.Errorf("no verse in supporting documents")
 	}
-	return lng, nil
+	return ml, nil
 }
 
 // Load loads the extracted document from TransFile; subsequent calls to Load
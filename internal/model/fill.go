@@ -0,0 +1,101 @@
+package model
+
+import (
+	"math/rand"
+
+	"github.com/jcorbin/markov/internal/symbol"
+)
+
+// Reverse returns a new transition table with every transition reversed:
+// for each a -> b with weight w, the result has b -> a with weight w.
+func (ts Trans) Reverse() Trans {
+	rev := make(Trans, len(ts))
+	for a, ws := range ts {
+		for b, w := range ws {
+			rev.Add(b, a, w)
+		}
+	}
+	return rev
+}
+
+// Reversed returns a new language, sharing this language's dictionary, with
+// a reversed transition table; chaining through it from a symbol generates
+// the symbols that may precede it, so chaining from the 0 symbol generates
+// backwards from the end.
+func (lng Lang) Reversed() Lang {
+	return Lang{
+		Dict:  lng.Dict,
+		Trans: lng.Trans.Reverse(),
+	}
+}
+
+// GenFill generates a chain of symbols bridging from a to b (exclusive), by
+// growing a forward chain from a through ts and a backward chain from b
+// through rev (which must be ts.Reverse()) until they meet. Either a or b may
+// be the 0 symbol, meaning the start or end of a chain respectively. Returns
+// false if either chain dead ends, or if they haven't met after maxLen total
+// symbols.
+func GenFill(rng *rand.Rand, ts, rev Trans, a, b symbol.Symbol, maxLen int) ([]symbol.Symbol, bool) {
+	if ts[a][b] > 0 {
+		return nil, true
+	}
+
+	// fwd chains forward from a, bwd chains backward from b; the *At maps
+	// index the first occurrence of each symbol in them.
+	fwd := []symbol.Symbol{a}
+	bwd := []symbol.Symbol{b}
+	fwdAt := map[symbol.Symbol]int{a: 0}
+	bwdAt := map[symbol.Symbol]int{b: 0}
+
+	// join returns the bridge fwd[1:i+1] then bwd[1:j+1] reversed, which is
+	// valid when fwd[i] transitions to bwd[j].
+	join := func(i, j int) []symbol.Symbol {
+		out := make([]symbol.Symbol, 0, i+j)
+		out = append(out, fwd[1:i+1]...)
+		for k := j; k > 0; k-- {
+			out = append(out, bwd[k])
+		}
+		return out
+	}
+
+	// NOTE: meetings are detected as soon as a symbol is added, so neither
+	// chain can reach the other's origin without meeting first.
+	for len(fwd)+len(bwd)-2 < maxLen {
+		next := ts.Choose(rng, fwd[len(fwd)-1], nil)
+		if next == 0 {
+			return nil, false
+		}
+		fwd = append(fwd, next)
+		i := len(fwd) - 1
+		if j, met := bwdAt[next]; met {
+			return join(i, j-1), true
+		}
+		for sym := range ts[next] {
+			if j, met := bwdAt[sym]; met {
+				return join(i, j), true
+			}
+		}
+		if _, def := fwdAt[next]; !def {
+			fwdAt[next] = i
+		}
+
+		prev := rev.Choose(rng, bwd[len(bwd)-1], nil)
+		if prev == 0 {
+			return nil, false
+		}
+		bwd = append(bwd, prev)
+		j := len(bwd) - 1
+		if i, met := fwdAt[prev]; met {
+			return join(i, j-1), true
+		}
+		for sym := range rev[prev] {
+			if i, met := fwdAt[sym]; met {
+				return join(i, j), true
+			}
+		}
+		if _, def := bwdAt[prev]; !def {
+			bwdAt[prev] = j
+		}
+	}
+	return nil, false
+}
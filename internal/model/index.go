@@ -0,0 +1,173 @@
+package model
+
+import "strings"
+
+// DocIndex maps normalized keys (e.g. author names) to document ids.
+type DocIndex map[string][]string
+
+// IndexKey normalizes an index key: case folded, with space collapsed.
+func IndexKey(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Add indexes a document id under a key.
+func (di DocIndex) Add(key, id string) {
+	if key = IndexKey(key); key != "" {
+		di[key] = append(di[key], id)
+	}
+}
+
+// Lookup returns the ids of documents indexed under a key.
+func (di DocIndex) Lookup(key string) []string {
+	return di[IndexKey(key)]
+}
+
+// LanguageNames names common language codes as e-book headers do.
+var LanguageNames = map[string]string{
+	"de": "German",
+	"en": "English",
+	"eo": "Esperanto",
+	"es": "Spanish",
+	"fi": "Finnish",
+	"fr": "French",
+	"el": "Greek",
+	"hu": "Hungarian",
+	"it": "Italian",
+	"la": "Latin",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"sv": "Swedish",
+	"zh": "Chinese",
+}
+
+// LanguageCode returns the code of a header "Language" value, e.g. "en" for
+// "English"; only the first of several languages (e.g. "English and French")
+// is considered. Unknown names return "", but codes are returned as is,
+// including lower case ones without a name, as the catalog leaves them.
+func LanguageCode(lang string) string {
+	if i := strings.Index(lang, " and "); i >= 0 {
+		lang = lang[:i]
+	}
+	lang = strings.TrimSpace(lang)
+	if _, ok := LanguageNames[strings.ToLower(lang)]; ok {
+		return strings.ToLower(lang)
+	}
+	for code, name := range LanguageNames {
+		if strings.EqualFold(name, lang) {
+			return code
+		}
+	}
+	if isCode(lang) {
+		return lang
+	}
+	return ""
+}
+
+// isCode returns true for a 2 or 3 letter lower case language code.
+func isCode(lang string) bool {
+	if len(lang) < 2 || len(lang) > 3 {
+		return false
+	}
+	for _, r := range lang {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// DocsLanguage returns the most common language code among the identified
+// documents, or "" if none is known; ties go to the least code.
+func (db DocDB) DocsLanguage(ids []string) string {
+	counts := make(map[string]int)
+	for _, id := range ids {
+		if code := LanguageCode(db.Docs[id].Info["Language"]); code != "" {
+			counts[code]++
+		}
+	}
+	best := ""
+	for code, n := range counts {
+		if n > counts[best] || (n == counts[best] && code < best) {
+			best = code
+		}
+	}
+	return best
+}
+
+// IndexInfo adds a document to the author, language and subject indexes,
+// based on its Project Gutenberg header info; multi-valued subjects may be
+// separated by newlines or semicolons.
+func (db *DocDB) IndexInfo(id string, info map[string]string) {
+	if db.Authors == nil {
+		db.Authors = make(DocIndex)
+	}
+	if db.Languages == nil {
+		db.Languages = make(DocIndex)
+	}
+	if db.Subjects == nil {
+		db.Subjects = make(DocIndex)
+	}
+	db.Authors.Add(info["Author"], id)
+	db.Languages.Add(info["Language"], id)
+	for _, subject := range infoSubjects(info) {
+		db.Subjects.Add(subject, id)
+	}
+}
+
+func infoSubjects(info map[string]string) []string {
+	var subjects []string
+	for _, key := range []string{"Subject", "LoCC"} {
+		subjects = append(subjects, strings.FieldsFunc(info[key], func(r rune) bool {
+			return r == '\n' || r == ';'
+		})...)
+	}
+	return subjects
+}
+
+// AddTitleLangs adds a document's title words to the per-author and/or
+// per-subject title languages, based on its header info.
+func (db *DocDB) AddTitleLangs(info map[string]string, words []string, authors, subjects bool) {
+	if authors {
+		if db.AuthorTitleLangs == nil {
+			db.AuthorTitleLangs = make(map[string]Lang)
+		}
+		addTitleLang(db.AuthorTitleLangs, info["Author"], words)
+	}
+	if subjects {
+		if db.SubjectTitleLangs == nil {
+			db.SubjectTitleLangs = make(map[string]Lang)
+		}
+		for _, subject := range infoSubjects(info) {
+			addTitleLang(db.SubjectTitleLangs, subject, words)
+		}
+	}
+}
+
+func addTitleLang(langs map[string]Lang, key string, words []string) {
+	if key = IndexKey(key); key == "" {
+		return
+	}
+	lng, def := langs[key]
+	if !def {
+		lng = MakeLang()
+		langs[key] = lng
+	}
+	lng.AddWords(words)
+}
+
+// PruneTitleLangs drops per-author and per-subject title languages built
+// from fewer than minDocs documents; such languages can hardly generate
+// anything but their few titles.
+func (db *DocDB) PruneTitleLangs(minDocs int) {
+	for key := range db.AuthorTitleLangs {
+		if len(db.Authors[key]) < minDocs {
+			delete(db.AuthorTitleLangs, key)
+		}
+	}
+	for key := range db.SubjectTitleLangs {
+		if len(db.Subjects[key]) < minDocs {
+			delete(db.SubjectTitleLangs, key)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestLanguageCode(t *testing.T) {
+	for _, tc := range []struct {
+		lang, want string
+	}{
+		{"English", "en"},
+		{"french", "fr"},
+		{"English and French", "en"},
+		{"de", "de"},
+		{"Klingon", ""},
+		{"ang", "ang"},
+		{"English and ang", "en"},
+		{"Ido", ""},
+		{"", ""},
+	} {
+		if got := LanguageCode(tc.lang); got != tc.want {
+			t.Errorf("LanguageCode(%q) = %q, want %q", tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestDocsLanguage(t *testing.T) {
+	db := DocDB{Docs: map[string]DocInfo{
+		"1": {Info: map[string]string{"Language": "French"}},
+		"2": {Info: map[string]string{"Language": "English"}},
+		"3": {Info: map[string]string{"Language": "French and English"}},
+		"4": {Info: map[string]string{}},
+	}}
+	for _, tc := range []struct {
+		ids  []string
+		want string
+	}{
+		{[]string{"1", "2", "3"}, "fr"},
+		{[]string{"1", "2"}, "en"},
+		{[]string{"4"}, ""},
+	} {
+		if got := db.DocsLanguage(tc.ids); got != tc.want {
+			t.Errorf("DocsLanguage(%q) = %q, want %q", tc.ids, got, tc.want)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package model
 
-import "github.com/jcorbin/markov/internal/symbol"
+import (
+	"fmt"
+
+	"github.com/jcorbin/markov/internal/symbol"
+)
 
 // Lang represents a language as its dictionary and transition table.
 type Lang struct {
@@ -8,6 +12,10 @@ type Lang struct {
 	Trans Trans        `json:"transitions"`
 }
 
+// LineBreak is the word that ends each line of verse in a verse language;
+// stanzas are ended by symbol.GS, as paragraphs are in prose.
+const LineBreak = "\x1e"
+
 // MakeLang creates a new lang.
 func MakeLang() Lang {
 	return Lang{
@@ -25,3 +33,27 @@ func (lng Lang) Merge(other Lang) Lang {
 		Trans: lng.Trans.Merge(other.Trans, rewrite),
 	}
 }
+
+// Symbolize returns the symbols for the given words, failing if any word is
+// not in the dictionary.
+func (lng Lang) Symbolize(words []string) ([]symbol.Symbol, error) {
+	syms := make([]symbol.Symbol, len(words))
+	for i, word := range words {
+		sym, def := lng.Dict.GetSym(word)
+		if !def {
+			return nil, fmt.Errorf("word %q not in dictionary", word)
+		}
+		syms[i] = sym
+	}
+	return syms, nil
+}
+
+// AddWords adds a chain of words to the language, from and back to the 0
+// symbol.
+func (lng Lang) AddWords(words []string) {
+	chain := make([]symbol.Symbol, len(words))
+	for i, word := range words {
+		chain[i] = lng.Dict.Add(word)
+	}
+	lng.Trans.AddChain(chain)
+}
@@ -0,0 +1,42 @@
+package model
+
+import "testing"
+
+func TestLangMerge(t *testing.T) {
+	a := MakeLang()
+	a.AddWords([]string{"the", "cat", "sat"})
+	b := MakeLang()
+	b.AddWords([]string{"the", "dog", "sat"})
+	c := MakeLang()
+	c.AddWords([]string{"the", "cat", "ran"})
+
+	m := a.Merge(b).Merge(c)
+
+	for _, tc := range []struct {
+		from, to string
+		want     uint
+	}{
+		{"the", "cat", 2},
+		{"the", "dog", 1},
+		{"cat", "sat", 1},
+		{"cat", "ran", 1},
+		{"dog", "sat", 1},
+	} {
+		syms, err := m.Symbolize([]string{tc.from, tc.to})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Trans[syms[0]][syms[1]]; got != tc.want {
+			t.Errorf("%q -> %q: got weight %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+
+	// merging copies, leaving every language as it was
+	if _, def := a.Dict.GetSym("dog"); def {
+		t.Errorf("Merge modified its receiver's dictionary")
+	}
+	syms, _ := a.Symbolize([]string{"the", "cat"})
+	if got := a.Trans[syms[0]][syms[1]]; got != 1 {
+		t.Errorf("Merge modified its receiver's transitions: the -> cat weight %v", got)
+	}
+}
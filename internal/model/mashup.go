@@ -0,0 +1,114 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jcorbin/markov/internal/symbol"
+)
+
+// MashUp is a title mash-up template: a space separated list of literal
+// words and slots; the slots are:
+//   - {title} an existing title
+//   - {phrase} a phrase from an existing title, delimited by stop words
+//   - {gen} a title generated by chaining through the title language
+type MashUp string
+
+// DefaultMashUps are mash-up templates used unless otherwise specified.
+var DefaultMashUps = []MashUp{
+	"{title} and the {phrase}",
+	"{phrase} of {phrase}",
+	"the {phrase} of {title}",
+	"{title} and {gen}",
+}
+
+// Validate returns an error if the template has an unknown slot.
+func (tmpl MashUp) Validate() error {
+	for _, part := range strings.Fields(string(tmpl)) {
+		switch part {
+		case "{title}", "{phrase}", "{gen}":
+		default:
+			if strings.HasPrefix(part, "{") {
+				return fmt.Errorf("invalid mash-up slot %q in %q", part, tmpl)
+			}
+		}
+	}
+	return nil
+}
+
+// TitleIDs returns the ids of all documents in sorted order, e.g. to use as a
+// GenMashUpTitle pool.
+func (db DocDB) TitleIDs() []string {
+	ids := make([]string, 0, len(db.Docs))
+	for id := range db.Docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GenMashUpTitle generates a title by filling in a mash-up template, drawing
+// existing titles from the given pool of document ids; supporting document
+// ids are collected as with GenTitle.
+func (db DocDB) GenMashUpTitle(rng *rand.Rand, tmpl MashUp, pool []string) (string, SupportDocIDs, error) {
+	if len(pool) == 0 {
+		return "", nil, fmt.Errorf("no titles to mash up")
+	}
+	if err := tmpl.Validate(); err != nil {
+		return "", nil, err
+	}
+	stop := db.stopWords()
+	var words []string
+	for _, part := range strings.Fields(string(tmpl)) {
+		switch part {
+		case "{title}":
+			words = append(words, db.titleWords(pool[rng.Intn(len(pool))])...)
+		case "{phrase}":
+			words = append(words, randomPhrase(rng, db.titleWords(pool[rng.Intn(len(pool))]), stop)...)
+		case "{gen}":
+			_ = db.TitleLang.Trans.GenChain(rng, func(sym symbol.Symbol) error {
+				if sym != 0 {
+					words = append(words, db.TitleLang.Dict.ToString(sym))
+				}
+				return nil
+			})
+		default:
+			words = append(words, part)
+		}
+	}
+	return strings.Join(words, " "), db.TitleDocs(words), nil
+}
+
+// titleWords returns the lower cased words of a document's title, dropping
+// any punctuation.
+func (db DocDB) titleWords(id string) []string {
+	return strings.FieldsFunc(strings.ToLower(db.Docs[id].Title), func(r rune) bool {
+		switch r {
+		case '-', '\'', '’':
+			return false
+		}
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// randomPhrase returns a random run of words not containing any stop words;
+// if there are none, all words are returned.
+func randomPhrase(rng *rand.Rand, words []string, stop map[string]bool) []string {
+	var phrases [][]string
+	start := 0
+	for i := 0; i <= len(words); i++ {
+		if i == len(words) || stop[words[i]] {
+			if i > start {
+				phrases = append(phrases, words[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if len(phrases) == 0 {
+		return words
+	}
+	return phrases[rng.Intn(len(phrases))]
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestMashUpValidate(t *testing.T) {
+	for _, tc := range []struct {
+		tmpl MashUp
+		ok   bool
+	}{
+		{"{title} and the {phrase}", true},
+		{"{gen} of {title}", true},
+		{"just words", true},
+		{"{title} and {bogus}", false},
+		{"{phrase", false},
+	} {
+		if err := tc.tmpl.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%q.Validate() = %v", tc.tmpl, err)
+		}
+	}
+}
+
+func TestGenMashUpTitle(t *testing.T) {
+	db := DocDB{
+		Docs: map[string]DocInfo{
+			"1": {Title: "The Cat of the Hills"},
+			"2": {Title: "Dog"},
+		},
+		StopWords: []string{"the", "of"},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		title, _, err := db.GenMashUpTitle(rng, "{phrase}", []string{"1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if title != "cat" && title != "hills" {
+			t.Fatalf("got phrase %q, want one delimited by stop words", title)
+		}
+	}
+	title, _, err := db.GenMashUpTitle(rng, "{title} and {title}", []string{"2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "dog and dog"; !strings.EqualFold(title, want) {
+		t.Errorf("got %q, want %q", title, want)
+	}
+	if _, _, err := db.GenMashUpTitle(rng, "{bogus}", []string{"2"}); err == nil {
+		t.Errorf("expected an invalid template error")
+	}
+}
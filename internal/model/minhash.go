@@ -0,0 +1,130 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// MinHashSize is the number of hash functions in a MinHash signature.
+	MinHashSize = 64
+
+	// ShingleSize is the number of words in each shingle fed to a MinHash.
+	ShingleSize = 5
+
+	// minHashBands is the number of locality sensitive hashing bands that
+	// signatures are split into to find candidate near duplicates; documents
+	// with Jaccard similarity s are candidates with probability
+	// 1-(1-s^r)^b, for b bands of r rows.
+	minHashBands = 16
+	minHashRows  = MinHashSize / minHashBands
+)
+
+// MinHash is a signature of a document's text, from which the Jaccard
+// similarity of the shingle sets of two documents may be estimated.
+type MinHash []uint64
+
+// MakeMinHash makes an empty MinHash signature.
+func MakeMinHash() MinHash {
+	mh := make(MinHash, MinHashSize)
+	for i := range mh {
+		mh[i] = math.MaxUint64
+	}
+	return mh
+}
+
+// Add adds a shingle hash, e.g. from a RollingHash, to the signature.
+func (mh MinHash) Add(shingle uint64) {
+	for i := range mh {
+		if h := mix(shingle ^ minHashSeeds[i]); h < mh[i] {
+			mh[i] = h
+		}
+	}
+}
+
+// Similarity estimates the Jaccard similarity of the shingles of two
+// documents from their signatures.
+func (mh MinHash) Similarity(other MinHash) float64 {
+	if len(mh) != len(other) || len(mh) == 0 {
+		return 0
+	}
+	n := 0
+	for i := range mh {
+		if mh[i] == other[i] && mh[i] != math.MaxUint64 {
+			n++
+		}
+	}
+	return float64(n) / float64(len(mh))
+}
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+var minHashSeeds = func() []uint64 {
+	seeds := make([]uint64, MinHashSize)
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		x += 0x9e3779b97f4a7c15
+		seeds[i] = mix(x)
+	}
+	return seeds
+}()
+
+// ClusterMinHashes clusters documents whose signatures are at least
+// threshold similar, returning a map from the first id (in sorted order) of
+// each cluster of two or more documents to the rest of its ids; a document
+// joins the first cluster that it's similar enough to.
+func ClusterMinHashes(sigs map[string]MinHash, threshold float64) map[string][]string {
+	ids := make([]string, 0, len(sigs))
+	for id := range sigs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	type bandKey struct {
+		band int
+		sum  uint64
+	}
+	buckets := make(map[bandKey][]string)
+	clusters := make(map[string][]string)
+	for _, id := range ids {
+		sig := sigs[id]
+		if len(sig) != MinHashSize {
+			continue
+		}
+		keys := make([]bandKey, minHashBands)
+		for band := range keys {
+			sum := uint64(band)
+			for _, h := range sig[band*minHashRows : (band+1)*minHashRows] {
+				sum = mix(sum ^ h)
+			}
+			keys[band] = bandKey{band, sum}
+		}
+
+		canon := ""
+	candidates:
+		for _, key := range keys {
+			for _, cand := range buckets[key] {
+				if sig.Similarity(sigs[cand]) >= threshold {
+					canon = cand
+					break candidates
+				}
+			}
+		}
+		if canon != "" {
+			clusters[canon] = append(clusters[canon], id)
+			continue
+		}
+		for _, key := range keys {
+			buckets[key] = append(buckets[key], id)
+		}
+	}
+	return clusters
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+// shingles returns a MinHash of the shingle hashes in [lo, hi).
+func shingles(ranges ...[2]uint64) MinHash {
+	mh := MakeMinHash()
+	for _, r := range ranges {
+		for h := r[0]; h < r[1]; h++ {
+			mh.Add(h)
+		}
+	}
+	return mh
+}
+
+func TestMinHash(t *testing.T) {
+	a := shingles([2]uint64{0, 1000})
+	near := shingles([2]uint64{0, 950}, [2]uint64{5000, 5050}) // Jaccard 0.9
+	far := shingles([2]uint64{10000, 11000})
+
+	if s := a.Similarity(a); s != 1 {
+		t.Errorf("self similarity %v, want 1", s)
+	}
+	if s := a.Similarity(near); s < 0.8 {
+		t.Errorf("near similarity %v, want about 0.9", s)
+	}
+	if s := a.Similarity(far); s > 0.1 {
+		t.Errorf("far similarity %v, want about 0", s)
+	}
+	if s := MakeMinHash().Similarity(MakeMinHash()); s != 0 {
+		t.Errorf("empty similarity %v, want 0", s)
+	}
+}
+
+func TestClusterMinHashes(t *testing.T) {
+	sigs := map[string]MinHash{
+		"1": shingles([2]uint64{0, 1000}),
+		"2": shingles([2]uint64{10000, 11000}),
+		"3": shingles([2]uint64{0, 950}, [2]uint64{5000, 5050}),
+		"4": shingles([2]uint64{20000, 21000}),
+		"5": shingles([2]uint64{10000, 10980}),
+		"6": nil, // e.g. an empty document
+	}
+	want := map[string][]string{
+		"1": {"3"},
+		"2": {"5"},
+	}
+	if got := ClusterMinHashes(sigs, 0.8); !reflect.DeepEqual(got, want) {
+		t.Errorf("got clusters %v, want %v", got, want)
+	}
+}
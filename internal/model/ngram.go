@@ -0,0 +1,91 @@
+package model
+
+import "hash/fnv"
+
+// rollBase is the multiplier used by RollingHash.
+const rollBase = 1099511628211
+
+// RollingHash is a Rabin-Karp hash over the last N tokens pushed into it.
+type RollingHash struct {
+	toks []uint64
+	pow  uint64
+	i    int
+	n    int
+	sum  uint64
+}
+
+// NewRollingHash creates a RollingHash over n tokens.
+func NewRollingHash(n int) *RollingHash {
+	pow := uint64(1)
+	for i := 1; i < n; i++ {
+		pow *= rollBase
+	}
+	return &RollingHash{
+		toks: make([]uint64, n),
+		pow:  pow,
+	}
+}
+
+// Push adds a token, returning the hash of the last N tokens, and whether N
+// tokens have been pushed since the last Reset.
+func (rh *RollingHash) Push(tok string) (uint64, bool) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(tok))
+	th := h.Sum64()
+	if rh.n == len(rh.toks) {
+		rh.sum -= rh.toks[rh.i] * rh.pow
+	} else {
+		rh.n++
+	}
+	rh.sum = rh.sum*rollBase + th
+	rh.toks[rh.i] = th
+	rh.i = (rh.i + 1) % len(rh.toks)
+	return rh.sum, rh.n == len(rh.toks)
+}
+
+// Reset clears any pushed tokens.
+func (rh *RollingHash) Reset() {
+	rh.i, rh.n, rh.sum = 0, 0, 0
+}
+
+// NGramIndex maps the hash of every N token sequence in a set of documents to
+// the ids of the documents containing it.
+type NGramIndex struct {
+	N     int
+	grams map[uint64][]string
+}
+
+// NewNGramIndex creates a new index of n token sequences.
+func NewNGramIndex(n int) *NGramIndex {
+	return &NGramIndex{
+		N:     n,
+		grams: make(map[uint64][]string),
+	}
+}
+
+// Add indexes all token sequences in a document; any "" tokens break the
+// sequence (e.g. at paragraph ends). Documents should be added in id order,
+// so that Sources returns sorted ids.
+func (idx *NGramIndex) Add(id string, toks []string) {
+	rh := NewRollingHash(idx.N)
+	for _, tok := range toks {
+		if tok == "" {
+			rh.Reset()
+			continue
+		}
+		sum, full := rh.Push(tok)
+		if !full {
+			continue
+		}
+		ids := idx.grams[sum]
+		if len(ids) == 0 || ids[len(ids)-1] != id {
+			idx.grams[sum] = append(ids, id)
+		}
+	}
+}
+
+// Sources returns the ids of all documents containing the sequence with the
+// given hash.
+func (idx *NGramIndex) Sources(sum uint64) []string {
+	return idx.grams[sum]
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/jcorbin/markov/internal/symbol"
+)
+
+// MergedLang is a language merged from supporting documents, which retains
+// each document's language so that it can answer provenance queries.
+type MergedLang struct {
+	Lang
+	IDs   []string
+	Parts []Lang
+}
+
+// DocShare is a document's contribution to a merged transition.
+type DocShare struct {
+	ID     string  `json:"id"`
+	Weight uint    `json:"weight"`
+	Share  float64 `json:"share"`
+}
+
+// Sources returns the documents that contributed the a -> b transition, in
+// descending order of weight.
+func (ml MergedLang) Sources(a, b symbol.Symbol) []DocShare {
+	astr, _ := ml.Dict.Get(a)
+	bstr, _ := ml.Dict.Get(b)
+	var (
+		shares []DocShare
+		total  uint
+	)
+	for i, part := range ml.Parts {
+		pa, def := part.Dict.GetSym(astr)
+		if !def {
+			continue
+		}
+		pb, def := part.Dict.GetSym(bstr)
+		if !def {
+			continue
+		}
+		if w := part.Trans[pa][pb]; w > 0 {
+			shares = append(shares, DocShare{ID: ml.IDs[i], Weight: w})
+			total += w
+		}
+	}
+	for i := range shares {
+		shares[i].Share = float64(shares[i].Weight) / float64(total)
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Weight != shares[j].Weight {
+			return shares[i].Weight > shares[j].Weight
+		}
+		return shares[i].ID < shares[j].ID
+	})
+	return shares
+}
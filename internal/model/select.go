@@ -0,0 +1,122 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Ranking is a strategy for selecting the most useful supporting documents.
+type Ranking uint8
+
+const (
+	// RankRelevance ranks by TF-IDF relevance to the title.
+	RankRelevance Ranking = iota
+
+	// RankIDF ranks by the inverse document frequency of the supporting
+	// word, favoring documents that support rarer words.
+	RankIDF
+
+	// RankLength ranks shorter documents first, bounding the time taken to
+	// load and merge them; documents of unknown length go last.
+	RankLength
+
+	// RankDiversity ranks by relevance, but alternates between authors.
+	RankDiversity
+)
+
+var rankingNames = []string{"relevance", "idf", "length", "diversity"}
+
+func (r Ranking) String() string {
+	if int(r) < len(rankingNames) {
+		return rankingNames[r]
+	}
+	return fmt.Sprintf("Ranking(%d)", r)
+}
+
+// ParseRanking parses a Ranking from its name.
+func ParseRanking(s string) (Ranking, error) {
+	for i, name := range rankingNames {
+		if s == name {
+			return Ranking(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown ranking %q, expected one of %q", s, rankingNames)
+}
+
+// Select returns at most max supporting documents, chosen by the given
+// ranking, each annotated with the Reason it was chosen (after any prior
+// reason). If max is not positive, all documents are returned, still
+// annotated.
+func (sdids SupportDocIDs) Select(db DocDB, max int, rank Ranking) SupportDocIDs {
+	ids := sdids.Ranked()
+	reason := func(id string, i int) string {
+		sd := sdids[id]
+		return fmt.Sprintf("#%d by relevance %.2f of %q", i+1, sd.Score, sd.Word)
+	}
+
+	switch rank {
+	case RankIDF:
+		idf := make(map[string]float64, len(ids))
+		for _, id := range ids {
+			idf[id] = db.IDF(sdids[id].Word)
+		}
+		sort.SliceStable(ids, func(i, j int) bool { return idf[ids[i]] > idf[ids[j]] })
+		reason = func(id string, i int) string {
+			return fmt.Sprintf("#%d by idf %.2f of %q", i+1, idf[id], sdids[id].Word)
+		}
+
+	case RankLength:
+		sort.SliceStable(ids, func(i, j int) bool {
+			a, b := db.Docs[ids[i]].Words, db.Docs[ids[j]].Words
+			if a == 0 || b == 0 {
+				return b == 0 && a != 0
+			}
+			return a < b
+		})
+		reason = func(id string, i int) string {
+			return fmt.Sprintf("#%d by length of %d words", i+1, db.Docs[id].Words)
+		}
+
+	case RankDiversity:
+		var authors []string
+		byAuthor := make(map[string][]string)
+		for _, id := range ids {
+			author := db.Docs[id].Info["Author"]
+			if _, def := byAuthor[author]; !def {
+				authors = append(authors, author)
+			}
+			byAuthor[author] = append(byAuthor[author], id)
+		}
+		nth := make(map[string]int, len(ids))
+		ids = ids[:0]
+		for n := 0; len(byAuthor) > 0; n++ {
+			for _, author := range authors {
+				if byID := byAuthor[author]; n < len(byID) {
+					nth[byID[n]] = n
+					ids = append(ids, byID[n])
+				} else {
+					delete(byAuthor, author)
+				}
+			}
+		}
+		reason = func(id string, i int) string {
+			return fmt.Sprintf("#%d by diversity; author %q's #%d by relevance %.2f",
+				i+1, db.Docs[id].Info["Author"], nth[id]+1, sdids[id].Score)
+		}
+	}
+
+	if max > 0 && len(ids) > max {
+		ids = ids[:max]
+	}
+	out := make(SupportDocIDs, len(ids))
+	for i, id := range ids {
+		sd := sdids[id]
+		if sd.Reason != "" {
+			sd.Reason += "; " + reason(id, i)
+		} else {
+			sd.Reason = reason(id, i)
+		}
+		out[id] = sd
+	}
+	return out
+}
@@ -32,10 +32,10 @@ func (ts Trans) Merge(other Trans, rewrite map[symbol.Symbol]symbol.Symbol) Tran
 		if rsym, def := rewrite[a]; def {
 			a = rsym
 		}
-		ws := ts[a]
+		ws := out[a]
 		if ws == nil {
 			ws = make(WeightedSymbols, len(ows))
-			ts[a] = ws
+			out[a] = ws
 		}
 		for b, w := range ows {
 			if rsym, def := rewrite[b]; def {
@@ -85,14 +85,7 @@ func (ts Trans) GenChain(rng *rand.Rand, f func(symbol.Symbol) error) error {
 func (ts Trans) GenReducedChain(rng *rand.Rand, f func(symbol.Symbol) (symbol.Symbol, error)) error {
 	var last symbol.Symbol
 	for {
-		var next symbol.Symbol
-		best := 1.0
-		for sym, w := range ts[last] {
-			if score := math.Pow(rng.Float64(), 1/float64(w)); score < best {
-				best, next = score, sym
-			}
-		}
-		next, err := f(next)
+		next, err := f(ts.Choose(rng, last, nil))
 		if err != nil {
 			return err
 		}
@@ -103,6 +96,69 @@ func (ts Trans) GenReducedChain(rng *rand.Rand, f func(symbol.Symbol) (symbol.Sy
 	}
 }
 
+// GenBiasedChain generates a chain through the transition table like
+// GenChain, but the given bias function may re-weight each candidate next
+// symbol; see Choose.
+func (ts Trans) GenBiasedChain(rng *rand.Rand, bias Bias, f func(symbol.Symbol) error) error {
+	return ts.GenBiasedChainFrom(rng, 0, bias, f)
+}
+
+// GenChainFrom is like GenChain, but continues the chain from the given
+// prior symbol (e.g. the last symbol of a seed prefix) rather than from the 0
+// symbol.
+func (ts Trans) GenChainFrom(rng *rand.Rand, last symbol.Symbol, f func(symbol.Symbol) error) error {
+	return ts.GenBiasedChainFrom(rng, last, nil, f)
+}
+
+// GenBiasedChainFrom is like GenBiasedChain, but continues the chain from
+// the given prior symbol.
+func (ts Trans) GenBiasedChainFrom(rng *rand.Rand, last symbol.Symbol, bias Bias, f func(symbol.Symbol) error) error {
+	for {
+		next := ts.Choose(rng, last, bias)
+		if err := f(next); err != nil {
+			return err
+		}
+		if next == symbol.Symbol(0) {
+			return nil
+		}
+		last = next
+	}
+}
+
+// Bias maps a candidate symbol and its table weight to an effective weight;
+// non-positive weights exclude the candidate.
+type Bias func(sym symbol.Symbol, w uint) float64
+
+// Choose randomly chooses the next symbol after last, with probability
+// proportional to its (possibly biased) weight. If the bias excludes every
+// candidate, the unbiased weights are used instead. Returns the 0 symbol if
+// last has no transitions.
+//
+// Each candidate draws u^(1/w), for uniform u, and the maximum is kept
+// (weighted reservoir sampling); this takes one random number per candidate,
+// so every GenChain caller, not just body generation, chains differently from
+// a given seed than a single cumulative weight draw would.
+func (ts Trans) Choose(rng *rand.Rand, last symbol.Symbol, bias Bias) symbol.Symbol {
+	var next symbol.Symbol
+	best := -1.0
+	for sym, w := range ts[last] {
+		bw := float64(w)
+		if bias != nil {
+			bw = bias(sym, w)
+		}
+		if bw <= 0 {
+			continue
+		}
+		if score := math.Pow(rng.Float64(), 1/bw); score > best {
+			best, next = score, sym
+		}
+	}
+	if best < 0 && bias != nil {
+		return ts.Choose(rng, last, nil)
+	}
+	return next
+}
+
 type jsonWS struct {
 	Weight uint          `json:"weight"`
 	Symbol symbol.Symbol `json:"symbol"`
@@ -71,10 +71,13 @@ func (d *Dict) Merge(other *Dict) (map[Symbol]Symbol, *Dict) {
 	for isym, str := range other.sym2str {
 		sym := Symbol(isym)
 		if _, def := rewrite[sym]; def {
-			newSym := Symbol(len(out.sym2str))
+			newSym, def := out.str2sym[str]
+			if !def {
+				newSym = Symbol(len(out.sym2str))
+				out.sym2str = append(out.sym2str, str)
+				out.str2sym[str] = newSym
+			}
 			rewrite[sym] = newSym
-			out.sym2str = append(out.sym2str, str)
-			out.str2sym[str] = newSym
 		}
 	}
 
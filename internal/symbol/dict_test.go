@@ -0,0 +1,39 @@
+package symbol
+
+import "testing"
+
+func TestDictMerge(t *testing.T) {
+	a := NewDict()
+	a.Add("cat")
+	a.Add("dog")
+	b := NewDict()
+	b.Add("dog")
+	b.Add("bird")
+	b.Add("cat")
+
+	rewrite, m := a.Merge(b)
+	remap := func(sym Symbol) Symbol {
+		if rsym, def := rewrite[sym]; def {
+			return rsym
+		}
+		return sym
+	}
+
+	// every string has one symbol, which other's symbols are rewritten to
+	for _, str := range []string{"cat", "dog", "bird"} {
+		bsym, _ := b.GetSym(str)
+		msym, def := m.GetSym(str)
+		if !def {
+			t.Fatalf("merged dict is missing %q", str)
+		}
+		if got := remap(bsym); got != msym {
+			t.Errorf("%q rewritten to %v, want %v", str, got, msym)
+		}
+	}
+	if got, want := m.Len(), a.Len()+1; got != want {
+		t.Errorf("merged dict has %v symbols, want %v", got, want)
+	}
+	if _, def := a.GetSym("bird"); def {
+		t.Errorf("Merge modified its receiver")
+	}
+}
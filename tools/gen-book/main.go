@@ -3,16 +3,68 @@ package main
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/jcorbin/markov/internal/gen"
+	"github.com/jcorbin/markov/internal/guten/archive"
+	"github.com/jcorbin/markov/internal/guten/extractor"
 	"github.com/jcorbin/markov/internal/model"
 )
 
 func main() {
+	length := gen.DefaultLength
+	titlePolicy := gen.DefaultTitlePolicy
+	var (
+		colophon    bool
+		verse       bool
+		chapterLen  = gen.DefaultChapterLength
+		provFile    string
+		maxVerbatim int
+		restriction gen.Restriction
+		maxSupport  int
+		supportRank = model.RankRelevance
+		mashUp      float64
+		mashUps     mashUpsFlag
+		titleCons   gen.Constraints
+		bodyCons    gen.Constraints
+	)
+	format := "text"
+	flag.StringVar(&format, "format", format, fmt.Sprintf("output format, one of %q", gen.Formats))
+	flag.IntVar(&length.Min, "minWords", length.Min, "generate at least this many body words")
+	flag.IntVar(&length.Max, "maxWords", length.Max, "end the body at the first paragraph end after this many words")
+	flag.BoolVar(&colophon, "colophon", false, "write a closing \"THE END\" colophon")
+	flag.IntVar(&chapterLen, "chapterWords", chapterLen, "start a new chapter at the first paragraph end after this many words; 0 for a single chapter")
+	flag.BoolVar(&verse, "verse", false, "generate a poem, from the supporting documents' verse, with line breaks and stanzas")
+	flag.StringVar(&provFile, "provenance", "", "write a JSON sidecar file mapping word offsets to supporting documents")
+	flag.IntVar(&maxVerbatim, "maxVerbatim", 0, "resample sentences that copy more than this many consecutive words from one supporting document; 0 disables")
+	flag.Var(wordsFlag{&titleCons.Start}, "titleStart", "start the title with these words")
+	flag.Var(wordsFlag{&titleCons.Include}, "titleMustInclude", "guide the title to include these words")
+	flag.Var(wordsFlag{&titleCons.End}, "titleEnd", "end the title with these words")
+	flag.Var(wordsFlag{&bodyCons.Start}, "start", "start the body with these words")
+	flag.Var(wordsFlag{&bodyCons.End}, "end", "end the body with these words")
+	flag.Var(wordsFlag{&bodyCons.Include}, "mustInclude", "guide the body to include these words")
+	flag.IntVar(&titlePolicy.Attempts, "titleAttempts", titlePolicy.Attempts, "generate at most this many titles before giving up")
+	flag.IntVar(&titlePolicy.MinWords, "titleMinWords", titlePolicy.MinWords, "minimum number of words in a title")
+	flag.IntVar(&titlePolicy.MaxWords, "titleMaxWords", titlePolicy.MaxWords, "maximum number of words in a title; 0 for no limit")
+	flag.IntVar(&titlePolicy.MinSupportWordLength, "minSupportWordLength", titlePolicy.MinSupportWordLength, "minimum length of a title word that supports a document")
+	flag.IntVar(&titlePolicy.MinSupportDocs, "minSupportDocs", titlePolicy.MinSupportDocs, "minimum number of supporting documents for a title")
+	flag.IntVar(&titlePolicy.MaxSupportDocs, "titleMaxSupportDocs", titlePolicy.MaxSupportDocs, "reject titles with more supporting documents than this, as too generic; 0 for no limit (see -maxSupportDocs to bound generation time)")
+	flag.BoolVar(&titlePolicy.RejectExisting, "rejectExistingTitles", titlePolicy.RejectExisting, "reject titles that duplicate an existing title, rather than just flagging them")
+	flag.IntVar(&titlePolicy.NearDistance, "nearTitleDistance", titlePolicy.NearDistance, "treat titles within this many character edits of an existing title as duplicates")
+	flag.StringVar(&restriction.Author, "author", "", "generate a book in the style of this author, using only their documents")
+	flag.StringVar(&restriction.Language, "language", "", "only use supporting documents in this language")
+	flag.StringVar(&restriction.Subject, "subject", "", "only use supporting documents about this subject or LoC class")
+	flag.IntVar(&maxSupport, "maxSupportDocs", 0, "generate from at most this many of a title's supporting documents, chosen by -supportRank, bounding generation time; 0 for no limit")
+	flag.Var(rankingFlag{&supportRank}, "supportRank", "ranking used to select supporting documents: relevance, idf, length, or diversity")
+	flag.Float64Var(&mashUp, "mashUp", 0, "probability of generating a mash-up title from a template")
+	flag.Var(&mashUps, "mashUpTemplate", "a mash-up title template, e.g. \"{title} and the {phrase}\"; may be given more than once")
+	hard := flag.Bool("hard", false, "reject any title or body missing a -titleMustInclude or -mustInclude word")
 	flag.Parse()
+	titleCons.Hard, bodyCons.Hard = *hard, *hard
 
 	dbFile := os.Stdin
 	if args := flag.Args(); len(args) > 0 {
@@ -23,21 +75,147 @@ func main() {
 		dbFile = f
 	}
 
-	if err := func(r io.Reader, w io.Writer) error {
+	if err := func(r io.Reader, w io.Writer) (rerr error) {
 		var db model.DocDB
 		dec := json.NewDecoder(r)
 		if err := dec.Decode(&db); err != nil {
 			return err
 		}
-		g := gen.New(db)
+		var stats gen.Stats
+		opts := []gen.Option{
+			gen.WithTitlePolicy(titlePolicy),
+			gen.WithLength(length),
+			gen.WithColophon(colophon),
+			gen.WithChapterLength(chapterLen),
+			gen.WithVerse(verse),
+			gen.WithTitleConstraints(titleCons),
+			gen.WithBodyConstraints(bodyCons),
+			gen.WithStats(&stats),
+			gen.WithMashUps(mashUp, mashUps...),
+			gen.WithRestriction(restriction),
+			gen.WithSupportSelection(maxSupport, supportRank),
+		}
+		if provFile != "" {
+			f, err := os.Create(provFile)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if cerr := f.Close(); rerr == nil {
+					rerr = cerr
+				}
+			}()
+			opts = append(opts, gen.WithProvenance(f))
+		}
+		defer func() {
+			log.Printf("titles: generated %v, %.1f%% duplicated existing titles",
+				stats.TitleAttempts, 100*stats.DuplicateRate())
+			if stats.AuthorFallback {
+				log.Printf("no supporting documents by author %q, using all of theirs", restriction.Author)
+			}
+			if stats.DuplicateOf != "" {
+				log.Printf("title duplicates existing title %q", stats.DuplicateOf)
+			}
+			if len(stats.MissingWords) > 0 {
+				log.Printf("unable to include words: %q", stats.MissingWords)
+			}
+		}()
+		if maxVerbatim > 0 {
+			opts = append(opts, gen.WithVerbatimLimit(maxVerbatim, loadTokens))
+			defer func() {
+				if stats.LongestVerbatim == 0 {
+					log.Printf("verbatim: rejected %v sentences, no copied spans", stats.VerbatimRejects)
+					return
+				}
+				log.Printf("verbatim: rejected %v sentences, longest copied span %v words at offset %v from %s",
+					stats.VerbatimRejects, stats.LongestVerbatim,
+					stats.LongestVerbatimOffset, db.DocName(stats.LongestVerbatimSource))
+			}()
+		}
+		g, err := gen.New(db, opts...)
+		if err != nil {
+			return err
+		}
 
 		title, docs, err := g.GenTitle()
 		if err != nil {
 			return err
 		}
+		for _, id := range docs.Ranked() {
+			log.Printf("supporting doc %s: %s", db.DocName(id), docs[id].Reason)
+		}
 
-		return g.GenBook(title, docs, w)
+		rend, err := gen.NewRenderer(format, w)
+		if err != nil {
+			return err
+		}
+		return g.RenderBook(title, docs, rend)
 	}(dbFile, os.Stdout); err != nil {
 		log.Fatalln(err)
 	}
 }
+
+// loadTokens re-extracts a document's tokens as guten-mine mined them: with
+// the default extractor options, which guten-mine has no flags to change.
+func loadTokens(di model.DocInfo) (toks []string, rerr error) {
+	f, err := archive.Open(di.SourceFile, di.SourceMember)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := f.Close(); rerr == nil {
+			rerr = cerr
+		}
+	}()
+	err = extractor.EachToken(f, func(tok string) error {
+		toks = append(toks, tok)
+		return nil
+	})
+	return toks, err
+}
+
+// wordsFlag is a flag.Value of space separated, lower cased, words.
+type wordsFlag struct{ words *[]string }
+
+func (wf wordsFlag) String() string {
+	if wf.words == nil {
+		return ""
+	}
+	return strings.Join(*wf.words, " ")
+}
+
+func (wf wordsFlag) Set(s string) error {
+	*wf.words = strings.Fields(strings.ToLower(s))
+	return nil
+}
+
+// mashUpsFlag is a flag.Value that collects mash-up templates.
+type mashUpsFlag []model.MashUp
+
+func (mf mashUpsFlag) String() string {
+	return fmt.Sprint([]model.MashUp(mf))
+}
+
+func (mf *mashUpsFlag) Set(s string) error {
+	tmpl := model.MashUp(s)
+	if err := tmpl.Validate(); err != nil {
+		return err
+	}
+	*mf = append(*mf, tmpl)
+	return nil
+}
+
+// rankingFlag is a flag.Value for a model.Ranking.
+type rankingFlag struct{ rank *model.Ranking }
+
+func (rf rankingFlag) String() string {
+	if rf.rank == nil {
+		return ""
+	}
+	return rf.rank.String()
+}
+
+func (rf rankingFlag) Set(s string) (err error) {
+	*rf.rank, err = model.ParseRanking(s)
+	return err
+}
@@ -33,7 +33,10 @@ func main() {
 		if err := dec.Decode(&db); err != nil {
 			return err
 		}
-		g := gen.New(db)
+		g, err := gen.New(db)
+		if err != nil {
+			return err
+		}
 
 		suchDocs := make(model.SupportDocIDs, 2*atLeast)
 
@@ -44,12 +47,13 @@ func main() {
 				return err
 			}
 			n := 0
-			for id, word := range docs {
-				if m := len(suchDocs[id]); m < len(word) {
-					suchDocs[id] = word
-					if m == 0 {
-						n++
-					}
+			for id, sd := range docs {
+				prior, def := suchDocs[id]
+				if !def {
+					n++
+				}
+				if !def || prior.Score < sd.Score {
+					suchDocs[id] = sd
 				}
 			}
 			log.Printf("added %v docs from %q", n, title)
@@ -57,7 +61,7 @@ func main() {
 
 		log.Printf("collected %v docs from %v titles", len(suchDocs), i)
 
-		var err error
+		err = nil
 		for enc, i, ids := json.NewEncoder(w), 0, suchDocs.SortedIDs(); err == nil && i < len(ids); {
 			id := ids[i]
 			di := db.Docs[id]
@@ -2,8 +2,6 @@ package main
 
 import (
 	"strings"
-	"unicode"
-	"unicode/utf8"
 
 	"github.com/jcorbin/markov/internal/guten/extractor"
 	"github.com/jcorbin/markov/internal/model"
@@ -13,7 +11,16 @@ import (
 type builder struct {
 	model.Doc
 
-	last symbol.Symbol
+	last    symbol.Symbol
+	words   int
+	shingle *model.RollingHash
+	minHash model.MinHash
+	matter  []string
+
+	// verse is set while within a verse paragraph, whose tokens go to the
+	// separate Verse language, chaining from verseLast.
+	verse     bool
+	verseLast symbol.Symbol
 }
 
 func (bld *builder) SetTitle(title string) error {
@@ -34,42 +41,70 @@ func (bld *builder) SetInfo(info map[string]string) error {
 }
 
 func (bld *builder) OnToken(tok []byte) error {
-	// TODO: handle numeric tokens specially
-
-	if r, width := utf8.DecodeRune(tok); width == len(tok) {
-		switch r {
+	stok, ok := extractor.Normalize(tok)
+	if !ok {
+		return nil
+	}
+	bld.words++
+	if bld.shingle == nil {
+		bld.shingle = model.NewRollingHash(model.ShingleSize)
+		bld.minHash = model.MakeMinHash()
+	}
+	if sum, ok := bld.shingle.Push(stok); ok {
+		bld.minHash.Add(sum)
+	}
+	if bld.verse {
+		return bld.advanceVerse(bld.Verse.Dict.Add(stok))
+	}
+	return bld.advance(bld.Lang.Dict.Add(stok))
+}
 
-		case '.', '!', '?':
-			return bld.advance(bld.Lang.Dict.Add(string(r)))
+func (bld *builder) EndParagraph() error {
+	if bld.verse {
+		bld.verse = false
+		return bld.advanceVerse(symbol.GS)
+	}
+	return bld.advance(symbol.GS)
+}
 
-		case ':': // TODO: could be a register/mode switch
-			return nil
+// BeginVerse starts a stanza in the Verse language.
+func (bld *builder) BeginVerse() error {
+	if bld.Verse == nil {
+		lng := model.MakeLang()
+		bld.Verse = &lng
+	}
+	bld.verse = true
+	return nil
+}
 
-		case ',': // TODO also ingest without comma
-			return nil
+// EndLine ends a line of verse with a model.LineBreak.
+func (bld *builder) EndLine() error {
+	return bld.advanceVerse(bld.Verse.Dict.Add(model.LineBreak))
+}
 
-		case ';': // TODO also ingest as an end/start-of-chain
+// OnMatter records the kinds of front and back matter skipped in the
+// document's "matter" info.
+func (bld *builder) OnMatter(m extractor.Matter, header string, paras [][]string) error {
+	kind := m.String()
+	for _, prior := range bld.matter {
+		if prior == kind {
 			return nil
-
-		default:
-			if unicode.IsPunct(r) {
-				// fmt.Printf(" SKIP<%s>", tok)
-				return nil
-			}
-
 		}
 	}
-
-	stok := string(tok)
-	stok = strings.ToLower(stok)
-	return bld.advance(bld.Lang.Dict.Add(stok))
-}
-
-func (bld *builder) EndParagraph() error {
-	return bld.advance(symbol.GS)
+	bld.matter = append(bld.matter, kind)
+	if bld.Info == nil {
+		bld.Info = make(map[string]string, 1)
+	}
+	bld.Info["matter"] = strings.Join(bld.matter, ", ")
+	return nil
 }
 
 func (bld *builder) Close() error {
+	if bld.Verse != nil {
+		if err := bld.advanceVerse(symbol.EOF); err != nil {
+			return err
+		}
+	}
 	return bld.advance(symbol.EOF)
 }
 
@@ -79,4 +114,14 @@ func (bld *builder) advance(sym symbol.Symbol) error {
 	return nil
 }
 
-var _ extractor.Resultor = &builder{}
+func (bld *builder) advanceVerse(sym symbol.Symbol) error {
+	bld.Verse.Trans.Add(bld.verseLast, sym, 1)
+	bld.verseLast = sym
+	return nil
+}
+
+var (
+	_ extractor.Resultor       = &builder{}
+	_ extractor.MatterResultor = &builder{}
+	_ extractor.VerseResultor  = &builder{}
+)
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jcorbin/markov/internal/guten/catalog"
+	"github.com/jcorbin/markov/internal/model"
+)
+
+var cat catalog.Catalog
+
+var ebookFilePat = regexp.MustCompile(`^(?:pg)?(\d+)(?:-\d+)?$`)
+
+// ebookNumber returns the Gutenberg e-book number of a document, from its
+// header, as found by the extractor, or else its file name (e.g. "1000.txt",
+// "1000-0.txt" or "pg1000.txt"), or 0 if unknown.
+func ebookNumber(info map[string]string) int {
+	if n, err := strconv.Atoi(info["EBOOK_NUMBER"]); err == nil && n > 0 {
+		return n
+	}
+	for _, key := range []string{"sourceMember", "sourceFile"} {
+		name := path.Base(info[key])
+		for ext := path.Ext(name); ext != ""; ext = path.Ext(name) {
+			name = strings.TrimSuffix(name, ext)
+		}
+		if m := ebookFilePat.FindStringSubmatch(name); len(m) > 0 {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// docID returns the id of a document in the DocDB: its e-book number, or
+// else its source path, since titles aren't unique.
+func docID(di model.DocInfo) string {
+	if n := ebookNumber(di.Info); n > 0 {
+		return strconv.Itoa(n)
+	}
+	if di.SourceMember != "" {
+		return path.Join(di.SourceFile, di.SourceMember)
+	}
+	return di.SourceFile
+}
+
+// joinCatalog overrides the document's header meta info, and title, with
+// its catalog entry, if any.
+func joinCatalog(bld *builder) error {
+	e, ok := cat[ebookNumber(bld.Info)]
+	if !ok {
+		return nil
+	}
+	for key, val := range e.Info() {
+		bld.Info[key] = val
+	}
+	if title := strings.SplitN(e.Title, "\n", 2)[0]; title != "" {
+		return bld.SetTitle(title)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jcorbin/markov/internal/model"
+)
+
+var vocab = strings.Fields(`
+	the a of and to in was she he it that with for on at by café naïve déjà
+	élan river house garden morning evening letter window door road hill
+	walked spoke waited looked smiled wrote found left long quiet old green
+`)
+
+// prose generates paragraphs of random sentences.
+func prose(seed int64, paras int) string {
+	rng := rand.New(rand.NewSource(seed))
+	var sb strings.Builder
+	for p := 0; p < paras; p++ {
+		for s := 0; s < 5; s++ {
+			for w := 0; w < 10; w++ {
+				if w > 0 {
+					sb.WriteByte(' ')
+				}
+				sb.WriteString(vocab[rng.Intn(len(vocab))])
+			}
+			sb.WriteString(".\n")
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// ebook formats an e-book, encoded as Latin-1 unless enc is UTF-8.
+func ebook(n int, title, enc, body string) []byte {
+	text := fmt.Sprintf(`The Project Gutenberg EBook of %[2]s, by Jane Doe
+
+Title: %[2]s
+Author: Jane Doe
+Release Date: May 1, 2004 [EBook #%[1]d]
+Character set encoding: %[3]s
+
+*** START OF THIS PROJECT GUTENBERG EBOOK %[4]s ***
+
+%[5]s
+*** END OF THIS PROJECT GUTENBERG EBOOK %[4]s ***
+`, n, title, enc, strings.ToUpper(title), body)
+	if enc == "UTF-8" {
+		return []byte(text)
+	}
+	buf := make([]byte, 0, len(text))
+	for _, r := range text {
+		buf = append(buf, byte(r))
+	}
+	return buf
+}
+
+func mine(t *testing.T, name string, text []byte) model.DocInfo {
+	bld, err := procio(bytes.NewReader(text), io.Discard, map[string]string{"sourceFile": name})
+	if err != nil {
+		t.Fatalf("failed to mine %q: %v", name, err)
+	}
+	return model.DocInfo{
+		SourceFile: name,
+		Title:      bld.Title,
+		Info:       bld.Info,
+		Words:      bld.words,
+		MinHash:    bld.minHash,
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	body := prose(1, 20)
+	note := "Transcriber's note: the spelling of the original is kept.\n\n"
+	utf8Ed := mine(t, "1000-0.txt", ebook(1000, "A Garden Road", "UTF-8", body))
+	latinEd := mine(t, "1000-8.txt", ebook(1000, "A Garden Road", "ISO-8859-1", body))
+	reissue := mine(t, "2000.txt", ebook(2000, "A Garden Road", "ISO-8859-1", note+body))
+	other := mine(t, "3000.txt", ebook(3000, "The Green Hill", "UTF-8", prose(2, 20)))
+
+	if s := utf8Ed.MinHash.Similarity(latinEd.MinHash); s != 1 {
+		t.Errorf("encodings of one edition are %v similar, want 1", s)
+	}
+	if s := utf8Ed.MinHash.Similarity(reissue.MinHash); s < 0.8 {
+		t.Errorf("reissue is %v similar, want at least 0.8", s)
+	}
+	if s := utf8Ed.MinHash.Similarity(other.MinHash); s > 0.2 {
+		t.Errorf("distinct text is %v similar, want about 0", s)
+	}
+
+	for _, order := range [][]model.DocInfo{
+		{latinEd, utf8Ed, reissue, other},
+		{utf8Ed, reissue, other, latinEd},
+	} {
+		db := model.DocDB{Docs: make(map[string]model.DocInfo)}
+		for _, di := range order {
+			collect(&db, di)
+		}
+		dedupe(&db, 0.8)
+
+		var ids []string
+		for id := range db.Docs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if want := []string{"1000", "3000"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("kept %q, want %q", ids, want)
+		}
+		if kept := db.Docs["1000"].SourceFile; kept != "1000-0.txt" {
+			t.Errorf("kept edition %q, want the UTF-8 one", kept)
+		}
+		var alts []string
+		for _, di := range db.Alternates["1000"] {
+			alts = append(alts, di.SourceFile)
+		}
+		sort.Strings(alts)
+		if want := []string{"1000-8.txt", "2000.txt"}; !reflect.DeepEqual(alts, want) {
+			t.Errorf("got alternates %q, want %q", alts, want)
+		}
+		if len(db.Alternates) != 1 {
+			t.Errorf("got alternates of %v documents, want only 1000's", len(db.Alternates))
+		}
+	}
+}
+
+func TestBetter(t *testing.T) {
+	edition := func(enc string, words int) model.DocInfo {
+		return model.DocInfo{
+			Info:  map[string]string{"Character set encoding": enc},
+			Words: words,
+		}
+	}
+	for _, tc := range []struct {
+		name string
+		a, b model.DocInfo
+		want bool
+	}{
+		{"unicode over latin-1", edition("UTF-8", 100), edition("ISO-8859-1", 200), true},
+		{"latin-1 under unicode", edition("ISO-8859-1", 200), edition("UTF-8", 100), false},
+		{"decodable over unknown", edition("ISO-8859-1", 100), edition("Big5", 200), true},
+		{"undeclared ties unknown", edition("", 100), edition("Big5", 200), false},
+		{"longer", edition("UTF-8", 200), edition("UTF-8", 100), true},
+		{"shorter", edition("UTF-8", 100), edition("UTF-8", 200), false},
+	} {
+		if got := better(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -12,24 +12,35 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
 
+	"github.com/jcorbin/markov/internal/guten/archive"
+	"github.com/jcorbin/markov/internal/guten/catalog"
 	"github.com/jcorbin/markov/internal/guten/extractor"
 	"github.com/jcorbin/markov/internal/guten/scanner"
 	"github.com/jcorbin/markov/internal/model"
-	"github.com/jcorbin/markov/internal/symbol"
 )
 
 var dbDir string
 
-func in2out(in string) string {
+func in2out(in, member string) string {
 	if dbDir == "" {
-		return strings.TrimSuffix(in, path.Ext(in)) + ".markov.json"
+		out := strings.TrimSuffix(in, path.Ext(in))
+		if member != "" {
+			out = path.Join(out, member)
+			out = strings.TrimSuffix(out, path.Ext(out))
+		}
+		return out + ".markov.json"
 	}
 	h := fnv.New64a()
 	h.Write([]byte(in))
+	if member != "" {
+		h.Write([]byte{0})
+		h.Write([]byte(member))
+	}
 	b := h.Sum(make([]byte, 0, 128))
 	return path.Join(
 		dbDir,
@@ -39,32 +50,78 @@ func in2out(in string) string {
 	) + ".markov.json"
 }
 
-func closeup(name string, f *os.File, rerr *error) func() {
-	return func() {
-		if cerr := f.Close(); *rerr == nil {
-			*rerr = fmt.Errorf("failed to close %q: %v", name, cerr)
-		}
+func closeup(name string, c io.Closer, rerr *error) {
+	if cerr := c.Close(); cerr != nil && *rerr == nil {
+		*rerr = fmt.Errorf("failed to close %q: %v", name, cerr)
+	}
+}
+
+func encodingRank(di model.DocInfo) int {
+	enc := di.Info["Character set encoding"]
+	switch {
+	case scanner.IsUnicode(enc):
+		return 0
+	case scanner.Decodable(enc):
+		return 1
+	default:
+		return 2
 	}
 }
 
-func prefer(a, b model.DocInfo) bool {
-	aenc := a.Info["Character set encoding"]
-	benc := b.Info["Character set encoding"]
+// collect adds a document to the DocDB, keeping the better edition of any
+// with the same id, and recording the other as its alternate.
+func collect(db *model.DocDB, di model.DocInfo) {
+	id := docID(di)
+	if prior, def := db.Docs[id]; !def {
+		db.Docs[id] = di
+		log.Printf("Collected %v %q => %+v", id, di.Title, di.Info)
+	} else if better(di, prior) {
+		db.Docs[id] = di
+		db.AddAlternate(id, prior)
+		log.Printf("Re-Collected %v %q => %+v", id, di.Title, di.Info)
+	} else {
+		db.AddAlternate(id, di)
+	}
+}
 
-	// TODO: update once we correct parse other encodings
-	for _, enc := range []string{"UTF-8", "Unicode", "ASCII", "US-ASCII"} {
-		if aenc == enc {
-			return false
+// dedupe clusters near duplicate documents by their MinHash, keeping the
+// preferred edition of each cluster, and recording the others as its
+// alternates.
+func dedupe(db *model.DocDB, threshold float64) {
+	sigs := make(map[string]model.MinHash, len(db.Docs))
+	for id, di := range db.Docs {
+		sigs[id] = di.MinHash
+	}
+	for first, rest := range model.ClusterMinHashes(sigs, threshold) {
+		keep := first
+		for _, id := range rest {
+			if better(db.Docs[id], db.Docs[keep]) {
+				keep = id
+			}
 		}
-		if benc == enc {
-			return true
+		for _, id := range append([]string{first}, rest...) {
+			if id == keep {
+				continue
+			}
+			log.Printf("Deduped %v %q as an edition of %v %q", id, db.Docs[id].Title, keep, db.Docs[keep].Title)
+			db.AddAlternate(keep, db.Docs[id])
+			for _, alt := range db.Alternates[id] {
+				db.AddAlternate(keep, alt)
+			}
+			delete(db.Alternates, id)
+			delete(db.Docs, id)
 		}
 	}
+}
 
-	// log.Printf("??? PREFER A: %+v", di.Info)
-	// log.Printf("??? PREFER B: %+v", prior.Info)
-
-	return false
+// better returns true if a should replace b as the kept edition of a
+// document: Unicode editions are preferred over those that the scanner
+// decodes, which are preferred over any others; then longer editions.
+func better(a, b model.DocInfo) bool {
+	if ra, rb := encodingRank(a), encodingRank(b); ra != rb {
+		return ra < rb
+	}
+	return a.Words > b.Words
 }
 
 func procio(r io.Reader, w io.Writer, info map[string]string) (builder, error) {
@@ -76,6 +133,9 @@ func procio(r io.Reader, w io.Writer, info map[string]string) (builder, error) {
 	}
 	gs := scanner.New(r, extractor.New(&bld)) // scanner.Dumper{}
 	err := gs.Scan()
+	if err == nil {
+		err = joinCatalog(&bld)
+	}
 	if err == nil {
 		enc := json.NewEncoder(w)
 		// enc.SetIndent("", "  ")
@@ -85,14 +145,29 @@ func procio(r io.Reader, w io.Writer, info map[string]string) (builder, error) {
 }
 
 func process(nin string, doneDocs chan<- model.DocInfo) {
+	members, err := archive.Members(nin)
+	if err != nil {
+		log.Printf("failed to open %q: %v", nin, err)
+		return
+	}
+	for _, member := range members {
+		processMember(nin, member, doneDocs)
+	}
+}
+
+func processMember(nin, member string, doneDocs chan<- model.DocInfo) {
+	name := nin
+	if member != "" {
+		name = path.Join(nin, member)
+	}
 	if err := func() (rerr error) {
-		nout := in2out(nin)
+		nout := in2out(nin, member)
 
-		fin, err := os.Open(nin)
+		fin, err := archive.Open(nin, member)
 		if err != nil {
-			return fmt.Errorf("failed to open %q: %v", nin, err)
+			return fmt.Errorf("failed to open %q: %v", name, err)
 		}
-		defer closeup(nin, fin, &rerr)
+		defer closeup(name, fin, &rerr)
 
 		fout, err := os.Create(nout)
 		if pe, ok := err.(*os.PathError); ok {
@@ -113,26 +188,33 @@ func process(nin string, doneDocs chan<- model.DocInfo) {
 			}
 		}()
 
-		log.Printf("processing %q", nin)
+		log.Printf("processing %q", name)
 
-		bld, err := procio(fin, fout, map[string]string{
+		info := map[string]string{
 			"sourceFile": nin,
-		})
+		}
+		if member != "" {
+			info["sourceMember"] = member
+		}
+		bld, err := procio(fin, fout, info)
 		if err != nil {
 			return err
 		}
 
 		doneDocs <- model.DocInfo{
-			SourceFile: nin,
-			TransFile:  nout,
-			Title:      bld.Title,
-			Info:       bld.Info,
+			SourceFile:   nin,
+			SourceMember: member,
+			TransFile:    nout,
+			Title:        bld.Title,
+			Info:         bld.Info,
+			Words:        bld.words,
+			MinHash:      bld.minHash,
 		}
 
-		log.Printf("processed %q", nin)
+		log.Printf("processed %q", name)
 		return nil
 	}(); err != nil {
-		log.Printf("failed to process %q: %v", nin, err)
+		log.Printf("failed to process %q: %v", name, err)
 	}
 }
 
@@ -140,8 +222,25 @@ func main() {
 	argsFromStdin := false
 	flag.BoolVar(&argsFromStdin, "stdin", false, "read path args from stdin")
 	flag.StringVar(&dbDir, "dbDir", "", "database directory in which to store extracted json; rather than beside source files")
+	stopWords := strings.Join(model.DefaultStopWords, ",")
+	flag.StringVar(&stopWords, "stopWords", stopWords, "comma separated title words to leave out of the inverted title index")
+	var authorTitleLangs, subjectTitleLangs bool
+	flag.BoolVar(&authorTitleLangs, "authorTitleLangs", false, "build a title language for each author")
+	flag.BoolVar(&subjectTitleLangs, "subjectTitleLangs", false, "build a title language for each subject")
+	minTitleLangDocs := flag.Int("minTitleLangDocs", 3, "drop author and subject title languages with fewer documents than this")
+	var dupThreshold float64
+	flag.Float64Var(&dupThreshold, "dupThreshold", 0.8, "merge documents whose text is at least this similar as editions of one document; 0 disables")
+	catFile := flag.String("catalog", "", "Project Gutenberg rdf-files.tar.bz2 catalog, whose metadata overrides e-book headers")
 	flag.Parse()
 
+	if *catFile != "" {
+		var err error
+		if cat, err = catalog.Load(*catFile); err != nil {
+			log.Fatalf("failed to load catalog %q: %v", *catFile, err)
+		}
+		log.Printf("loaded %v catalog entries", len(cat))
+	}
+
 	if !argsFromStdin && len(flag.Args()) == 0 {
 		if _, err := procio(os.Stdin, os.Stdout, map[string]string{
 			"sourceFile": "<stdin>",
@@ -171,40 +270,18 @@ func main() {
 		TitleLang: model.MakeLang(),
 		InvTW:     make(map[string][]string),
 	}
+	stop := make(map[string]bool)
+	for _, word := range strings.Split(stopWords, ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			db.StopWords = append(db.StopWords, word)
+			stop[word] = true
+		}
+	}
 
 	docDBDone := make(chan struct{})
 	go func() {
-		var buf bytes.Buffer
 		for di := range doneDocs {
-			id := di.Title
-			prior, def := db.Docs[id]
-
-			if !def {
-				// ingest the title for markov generation and inverted lookup
-				buf.Reset()
-				buf.WriteString(strings.ToLower(di.Title))
-				sc := bufio.NewScanner(&buf)
-				sc.Split(extractor.ScanTokens)
-				var last symbol.Symbol
-				for sc.Scan() {
-					word := sc.Text()
-
-					db.InvTW[word] = append(db.InvTW[word], id)
-
-					sym := db.TitleLang.Dict.Add(word)
-					db.TitleLang.Trans.Add(last, sym, 1)
-					last = sym
-				}
-				db.TitleLang.Trans.Add(last, symbol.Symbol(0), 1)
-			}
-
-			if !def {
-				db.Docs[id] = di
-				log.Printf("Indexed %v => %+v", id, di.Info)
-			} else if prefer(di, prior) {
-				db.Docs[id] = di
-				log.Printf("Re-Indexed %v => %+v", id, di.Info)
-			}
+			collect(&db, di)
 		}
 		docDBDone <- struct{}{}
 	}()
@@ -230,6 +307,43 @@ func main() {
 	close(doneDocs)
 
 	<-docDBDone
+	if dupThreshold > 0 {
+		dedupe(&db, dupThreshold)
+	}
+
+	ids := make([]string, 0, len(db.Docs))
+	for id := range db.Docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var buf bytes.Buffer
+	for _, id := range ids {
+		di := db.Docs[id]
+
+		// ingest the title for markov generation and inverted lookup
+		buf.Reset()
+		buf.WriteString(strings.ToLower(di.Title))
+		sc := bufio.NewScanner(&buf)
+		sc.Split(extractor.ScanTokens)
+		var words []string
+		for sc.Scan() {
+			word := sc.Text()
+
+			if !stop[word] {
+				db.InvTW[word] = append(db.InvTW[word], id)
+			}
+
+			words = append(words, word)
+		}
+		db.TitleLang.AddWords(words)
+		db.AddTitleLangs(di.Info, words, authorTitleLangs, subjectTitleLangs)
+
+		db.IndexInfo(id, di.Info)
+		log.Printf("Indexed %v %q", id, di.Title)
+	}
+
+	db.ComputeIDF()
+	db.PruneTitleLangs(*minTitleLangDocs)
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
 	if err := enc.Encode(db); err != nil {